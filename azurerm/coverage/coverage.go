@@ -4,22 +4,46 @@ import (
 	_ "embed"
 	"encoding/json"
 	"log"
+	"strconv"
 	"strings"
 )
 
 //go:embed tf.json
 var coverageJson string
 
-var cov []Coverage
+var cov Report
 
 func init() {
-	_ = json.Unmarshal([]byte(coverageJson), &cov)
-	for i := range cov {
-		cov[i].IdPattern = strings.ReplaceAll(cov[i].IdPattern, "/{}", "")
-	}
+	cov, _ = ParseReport([]byte(coverageJson))
 	if len(cov) <= 10 {
 		log.Printf("[WARN] Coverage report for DEVELOPMENT is loaded. Please use the released binaries in production.")
+	} else if !cov.HasAttributeMappings() {
+		log.Printf("[WARN] The coverage report doesn't map the API properties to the azurerm attributes, the references to the attributes and properties are not migrated.")
+	}
+}
+
+// ParseReport parses the coverage report in JSON format, the `{}` segments of the api paths are removed, so they're the id patterns
+func ParseReport(data []byte) (Report, error) {
+	var res Report
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].IdPattern = strings.ReplaceAll(res[i].IdPattern, "/{}", "")
 	}
+	return res, nil
+}
+
+// HasAttributeMappings returns true if any property in the report maps to an azurerm attribute
+func (report Report) HasAttributeMappings() bool {
+	for _, r := range report {
+		for _, p := range r.Properties {
+			if p.TerraformAddr != "" {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func GetApiVersion(idPattern string) string {
@@ -36,15 +60,25 @@ func GetApiVersion(idPattern string) string {
 }
 
 func GetPutCoverage(props []string, idPattern string) ([]string, []string) {
-	return getCoverage(props, "PUT", idPattern)
+	return cov.PutCoverage(props, idPattern)
 }
 
 func GetGetCoverage(props []string, idPattern string) ([]string, []string) {
-	return getCoverage(props, "GET", idPattern)
+	return cov.GetCoverage(props, idPattern)
 }
 
-func getCoverage(props []string, operation, idPattern string) ([]string, []string) {
-	for _, r := range cov {
+// PutCoverage returns the properties in `props` which are covered by the PUT operation on `idPattern`, and the uncovered ones
+func (report Report) PutCoverage(props []string, idPattern string) ([]string, []string) {
+	return report.coverage(props, "PUT", idPattern)
+}
+
+// GetCoverage returns the properties in `props` which are covered by the GET operation on `idPattern`, and the uncovered ones
+func (report Report) GetCoverage(props []string, idPattern string) ([]string, []string) {
+	return report.coverage(props, "GET", idPattern)
+}
+
+func (report Report) coverage(props []string, operation, idPattern string) ([]string, []string) {
+	for _, r := range report {
 		if r.Operation != operation {
 			continue
 		}
@@ -75,3 +109,64 @@ func getCoverage(props []string, operation, idPattern string) ([]string, []strin
 	}
 	return []string{}, props
 }
+
+// GetAttributePath returns the path of the azurerm attribute which API property `prop` maps to, e.g. `sku_name` for `properties.sku.name`.
+// The paths are separated by `.` and don't contain indexes. It returns empty string if the mapping isn't found.
+func GetAttributePath(prop string, idPattern string) string {
+	return cov.AttributePath(prop, idPattern)
+}
+
+// GetPropertyPath returns the path of the API property which azurerm attribute `attributePath` maps to, it's the reverse of GetAttributePath
+func GetPropertyPath(attributePath string, idPattern string) string {
+	return cov.PropertyPath(attributePath, idPattern)
+}
+
+// AttributePath returns the path of the azurerm attribute which API property `prop` maps to, see GetAttributePath
+func (report Report) AttributePath(prop string, idPattern string) string {
+	for _, operation := range []string{"PUT", "GET"} {
+		for _, r := range report {
+			if r.Operation != operation || !strings.EqualFold(idPattern, r.IdPattern) {
+				continue
+			}
+			for _, p := range r.Properties {
+				if p.TerraformAddr != "" && propertyPath(p.Name) == prop {
+					return propertyPath(p.TerraformAddr)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// PropertyPath returns the path of the API property which azurerm attribute `attributePath` maps to, see GetPropertyPath
+func (report Report) PropertyPath(attributePath string, idPattern string) string {
+	for _, operation := range []string{"PUT", "GET"} {
+		for _, r := range report {
+			if r.Operation != operation || !strings.EqualFold(idPattern, r.IdPattern) {
+				continue
+			}
+			for _, p := range r.Properties {
+				if p.TerraformAddr != "" && propertyPath(p.TerraformAddr) == attributePath {
+					return propertyPath(p.Name)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// propertyPath converts the address in the coverage report to a path separated by `.` without indexes, e.g. `properties/subnets{}/name`
+// and `properties/subnets/0/name` to `properties.subnets.name`, it's used to map the properties and attributes
+func propertyPath(addr string) string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(addr, "/") {
+		if index := strings.Index(part, "{"); index != -1 {
+			part = part[0:index]
+		}
+		if _, err := strconv.Atoi(part); err == nil {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ".")
+}
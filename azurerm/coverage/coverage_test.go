@@ -55,3 +55,92 @@ func Test_GetCoverage(t *testing.T) {
 		}
 	}
 }
+
+// report has the fields of `tf.json` in https://github.com/magodo/azure-rest-api-cov-terraform-reports, which are parsed by coverage.Report:
+// `api_version`, `api_path`, `operation` and `properties[].addr`. The optional `tf_addr` of a property is the azurerm attribute
+// which it maps to, the properties without it are not mapped.
+const report = `[
+  {
+    "api_version": "2023-07-01",
+    "api_path": "/subscriptions/{}/resourceGroups/{}/providers/Microsoft.KeyVault/vaults/{}",
+    "operation": "PUT",
+    "properties": [
+      {"addr": "location", "tf_addr": "location"},
+      {"addr": "tags", "tf_addr": "tags"},
+      {"addr": "properties/sku/name", "tf_addr": "sku_name"},
+      {"addr": "properties/tenantId", "tf_addr": "tenant_id"},
+      {"addr": "properties/networkAcls/bypass", "tf_addr": "network_acls/0/bypass"},
+      {"addr": "properties/networkAcls/ipRules{}/value", "tf_addr": "network_acls/0/ip_rules"},
+      {"addr": "properties/accessPolicies/0/objectId"},
+      {"addr": "properties/createMode"}
+    ]
+  },
+  {
+    "api_version": "2023-07-01",
+    "api_path": "/subscriptions/{}/resourceGroups/{}/providers/Microsoft.KeyVault/vaults/{}",
+    "operation": "GET",
+    "properties": [
+      {"addr": "properties/vaultUri", "tf_addr": "vault_uri"}
+    ]
+  }
+]`
+
+const keyVaultIdPattern = "/subscriptions/resourceGroups/providers/Microsoft.KeyVault/vaults"
+
+func Test_ReportPropertyMapping(t *testing.T) {
+	r, err := coverage.ParseReport([]byte(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.HasAttributeMappings() {
+		t.Fatal("expect the report to map the properties to attributes")
+	}
+
+	attributes := map[string]string{
+		"properties.sku.name":                  "sku_name",
+		"properties.networkAcls.bypass":        "network_acls.bypass",
+		"properties.networkAcls.ipRules":       "",
+		"properties.networkAcls.ipRules.value": "network_acls.ip_rules",
+		"properties.vaultUri":                  "vault_uri",
+		"properties.createMode":                "",
+	}
+	for prop, expected := range attributes {
+		if actual := r.AttributePath(prop, keyVaultIdPattern); actual != expected {
+			t.Errorf("expect property %s to map to attribute %q, but got %q", prop, expected, actual)
+		}
+	}
+	properties := map[string]string{
+		"sku_name":            "properties.sku.name",
+		"network_acls.bypass": "properties.networkAcls.bypass",
+		"vault_uri":           "properties.vaultUri",
+		"purge_protection":    "",
+	}
+	for attr, expected := range properties {
+		if actual := r.PropertyPath(attr, keyVaultIdPattern); actual != expected {
+			t.Errorf("expect attribute %s to map to property %q, but got %q", attr, expected, actual)
+		}
+	}
+
+	unmapped, err := coverage.ParseReport([]byte(`[{"api_version":"2023-07-01","api_path":"/subscriptions/{}","operation":"PUT","properties":[{"addr":"location"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unmapped.HasAttributeMappings() {
+		t.Fatal("expect the report without tf_addr not to map the properties")
+	}
+}
+
+func Test_ReportCoverageKeepsIndexes(t *testing.T) {
+	r, err := coverage.ParseReport([]byte(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the numeric segments are kept to check the coverage, only the `{}` suffixes are removed
+	covered, uncovered := r.PutCoverage([]string{"properties.accessPolicies.0.objectId", "properties.accessPolicies.objectId", "properties.networkAcls.ipRules.value"}, keyVaultIdPattern)
+	if !reflect.DeepEqual(covered, []string{"properties.accessPolicies.0.objectId", "properties.networkAcls.ipRules.value"}) {
+		t.Errorf("unexpected covered properties %v", covered)
+	}
+	if !reflect.DeepEqual(uncovered, []string{"properties.accessPolicies.objectId"}) {
+		t.Errorf("unexpected uncovered properties %v", uncovered)
+	}
+}
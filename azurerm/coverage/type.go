@@ -1,5 +1,10 @@
 package coverage
 
+// Report is the coverage report of the azurerm provider in `tf.json`, which is updated from
+// https://github.com/magodo/azure-rest-api-cov-terraform-reports by scripts/update-coverage-json.sh
+type Report []Coverage
+
+// Coverage is the properties of API operation `Operation` on `IdPattern` which are covered by the azurerm provider
 type Coverage struct {
 	ApiVersion string     `json:"api_version"`
 	IdPattern  string     `json:"api_path"`
@@ -9,4 +14,7 @@ type Coverage struct {
 
 type Property struct {
 	Name string `json:"addr"`
+	// TerraformAddr is the path of the azurerm attribute which the property maps to, e.g. `network_acls/bypass`. It's optional and empty if the report
+	// doesn't map the property, in which case the property isn't mapped to any attribute.
+	TerraformAddr string `json:"tf_addr,omitempty"`
 }
@@ -25,8 +25,8 @@ func tuneForBlock(rb *hclwrite.Body, sch *schema.SchemaBlock, parentAttrNames []
 	for attrName, attrVal := range rb.Attributes() {
 		schAttr, ok := sch.Attributes[attrName]
 		if !ok {
-			// This might because the provider under used is a newer one than the version where we ingest the schema information.
-			// This might happen when the user has a newer version provider installed in its local fs, and has set the "dev_overrides" for that provider.
+			// This might because the provider under used is a newer one than the version where we ingest the schema information,
+			// which only happens when the schema of the installed provider can't be loaded and the embedded schema is used.
 			// We simply remove that attribute from the config.
 			rb.RemoveAttribute(attrName)
 			continue
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// runtimeSchema is the schema of the installed azurerm provider, it's loaded by LoadProviderSchema
+var runtimeSchema *ProviderSchema
+
+// LoadProviderSchema loads the azurerm provider schema from the output of `terraform providers schema -json`, so the schema
+// follows the provider version used by the configuration instead of the embedded one. The core schema doesn't contain
+// the Default, ExactlyOneOf, AtLeastOneOf, ConflictsWith and RequiredWith information and whether a block is computed,
+// they're copied from the embedded schema when it has the same attribute or block.
+func LoadProviderSchema(pschs *tfjson.ProviderSchemas) error {
+	if pschs == nil {
+		return fmt.Errorf("no provider schema")
+	}
+	for source, psch := range pschs.Schemas {
+		if !strings.HasSuffix(strings.ToLower(source), "hashicorp/azurerm") || psch == nil {
+			continue
+		}
+		res := &ProviderSchema{
+			ResourceSchemas: make(map[string]*Schema),
+		}
+		for resourceType, sch := range psch.ResourceSchemas {
+			if sch == nil {
+				continue
+			}
+			var embeddedBlock *SchemaBlock
+			if embedded := ProviderSchemaInfo.ResourceSchemas[resourceType]; embedded != nil {
+				embeddedBlock = embedded.Block
+			}
+			res.ResourceSchemas[resourceType] = &Schema{
+				Block: fromCoreSchemaBlock(sch.Block, embeddedBlock),
+			}
+		}
+		runtimeSchema = res
+		return nil
+	}
+	return fmt.Errorf("schema of provider hashicorp/azurerm is not found")
+}
+
+// ResetProviderSchema discards the schema loaded by LoadProviderSchema, the embedded schema is used afterwards
+func ResetProviderSchema() {
+	runtimeSchema = nil
+}
+
+// ResourceSchema returns the schema of `resourceType`, the schema loaded by LoadProviderSchema is preferred,
+// the embedded schema is used if it's not loaded or doesn't contain the resource type.
+func ResourceSchema(resourceType string) *Schema {
+	if runtimeSchema != nil {
+		if sch := runtimeSchema.ResourceSchemas[resourceType]; sch != nil {
+			return sch
+		}
+	}
+	return ProviderSchemaInfo.ResourceSchemas[resourceType]
+}
+
+func fromCoreSchemaBlock(block *tfjson.SchemaBlock, embedded *SchemaBlock) *SchemaBlock {
+	res := &SchemaBlock{
+		Attributes:   make(map[string]*SchemaAttribute),
+		NestedBlocks: make(map[string]*SchemaBlockType),
+	}
+	if block == nil {
+		return res
+	}
+	for name, attr := range block.Attributes {
+		if attr == nil {
+			continue
+		}
+		schAttr := &SchemaAttribute{
+			AttributeType: attr.AttributeType,
+			Required:      attr.Required,
+			Optional:      attr.Optional,
+			Computed:      attr.Computed,
+		}
+		if embedded != nil && embedded.Attributes[name] != nil {
+			embeddedAttr := embedded.Attributes[name]
+			schAttr.Default = embeddedAttr.Default
+			schAttr.ConflictsWith = embeddedAttr.ConflictsWith
+			schAttr.ExactlyOneOf = embeddedAttr.ExactlyOneOf
+			schAttr.AtLeastOneOf = embeddedAttr.AtLeastOneOf
+			schAttr.RequiredWith = embeddedAttr.RequiredWith
+		}
+		res.Attributes[name] = schAttr
+	}
+	for name, blockType := range block.NestedBlocks {
+		if blockType == nil {
+			continue
+		}
+		var embeddedBlockType *SchemaBlockType
+		var embeddedBlock *SchemaBlock
+		if embedded != nil && embedded.NestedBlocks[name] != nil {
+			embeddedBlockType = embedded.NestedBlocks[name]
+			embeddedBlock = embeddedBlockType.Block
+		}
+		schBlockType := &SchemaBlockType{
+			NestingMode: fromCoreNestingMode(blockType.NestingMode),
+			Block:       fromCoreSchemaBlock(blockType.Block, embeddedBlock),
+			Required:    blockType.MinItems > 0,
+			Optional:    blockType.MinItems == 0,
+		}
+		if embeddedBlockType != nil {
+			schBlockType.Computed = embeddedBlockType.Computed
+			schBlockType.ConflictsWith = embeddedBlockType.ConflictsWith
+			schBlockType.ExactlyOneOf = embeddedBlockType.ExactlyOneOf
+			schBlockType.AtLeastOneOf = embeddedBlockType.AtLeastOneOf
+			schBlockType.RequiredWith = embeddedBlockType.RequiredWith
+		}
+		res.NestedBlocks[name] = schBlockType
+	}
+	return res
+}
+
+func fromCoreNestingMode(mode tfjson.SchemaNestingMode) NestingMode {
+	switch mode {
+	case tfjson.SchemaNestingModeSingle:
+		return NestingSingle
+	case tfjson.SchemaNestingModeGroup:
+		return NestingGroup
+	case tfjson.SchemaNestingModeList:
+		return NestingList
+	case tfjson.SchemaNestingModeSet:
+		return NestingSet
+	case tfjson.SchemaNestingModeMap:
+		return NestingMap
+	default:
+		return nestingModeInvalid
+	}
+}
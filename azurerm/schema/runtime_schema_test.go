@@ -0,0 +1,85 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/azurerm/schema"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_LoadProviderSchema(t *testing.T) {
+	input := `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/azurerm": {
+      "resource_schemas": {
+        "azurerm_storage_account": {
+          "version": 4,
+          "block": {
+            "attributes": {
+              "name": {"type": "string", "required": true},
+              "account_kind": {"type": "string", "optional": true},
+              "new_attribute": {"type": "bool", "optional": true}
+            },
+            "block_types": {
+              "network_rules": {
+                "nesting_mode": "list",
+                "block": {
+                  "attributes": {
+                    "default_action": {"type": "string", "required": true}
+                  }
+                },
+                "max_items": 1
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+	var pschs tfjson.ProviderSchemas
+	if err := json.Unmarshal([]byte(input), &pschs); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.LoadProviderSchema(&pschs); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(schema.ResetProviderSchema)
+
+	sch := schema.ResourceSchema("azurerm_storage_account")
+	if sch == nil || sch.Block == nil {
+		t.Fatal("expect schema of azurerm_storage_account, but got nil")
+	}
+	if _, ok := sch.Block.Attributes["new_attribute"]; !ok {
+		t.Fatalf("expect attribute new_attribute in the loaded schema")
+	}
+	if v := sch.Block.Attributes["account_kind"].Default; v != "StorageV2" {
+		t.Fatalf("expect default value of account_kind copied from the embedded schema, but got %v", v)
+	}
+	if sch.Block.NestedBlocks["network_rules"] == nil || sch.Block.NestedBlocks["network_rules"].Block.Attributes["default_action"] == nil {
+		t.Fatalf("expect block network_rules in the loaded schema")
+	}
+
+	// the resource types which are not in the loaded schema fall back to the embedded schema
+	if schema.ResourceSchema("azurerm_resource_group") == nil {
+		t.Fatalf("expect schema of azurerm_resource_group from the embedded schema, but got nil")
+	}
+
+	schema.ResetProviderSchema()
+	if _, ok := schema.ResourceSchema("azurerm_storage_account").Block.Attributes["new_attribute"]; ok {
+		t.Fatalf("expect the embedded schema after reset, but got the loaded one")
+	}
+}
+
+func Test_LoadProviderSchemaNotFound(t *testing.T) {
+	pschs := &tfjson.ProviderSchemas{
+		Schemas: map[string]*tfjson.ProviderSchema{
+			"registry.terraform.io/azure/azapi": {},
+		},
+	}
+	if err := schema.LoadProviderSchema(pschs); err == nil {
+		t.Fatal("expect error when the azurerm provider schema is not found, but got nil")
+	}
+}
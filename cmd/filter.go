@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+const filenameIgnore = "aztfmigrate.ignore"
+
+// AddressFilter selects the resources to migrate by Terraform address or resource type. The patterns support glob syntax,
+// e.g. `azapi_resource.*` or `azurerm_storage_*`. The addresses of the resources in child modules are matched with and without
+// the module path, e.g. both `module.network.azapi_resource.vnet` and `azapi_resource.vnet` match `module.network.azapi_resource.vnet`.
+type AddressFilter struct {
+	// Targets are the patterns of resources to migrate, all resources are selected if it's empty
+	Targets []string
+	// Excludes are the patterns of resources to skip, they take precedence over the targets
+	Excludes []string
+}
+
+// IsTargeted returns true if the resource is matched by the targets
+func (f AddressFilter) IsTargeted(address string, resourceType string) bool {
+	return len(f.Targets) == 0 || matchAnyPattern(f.Targets, address, resourceType)
+}
+
+// IsExcluded returns true if the resource is matched by the excludes
+func (f AddressFilter) IsExcluded(address string, resourceType string) bool {
+	return matchAnyPattern(f.Excludes, address, resourceType)
+}
+
+func matchAnyPattern(patterns []string, address string, resourceType string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, address) || matchPattern(pattern, moduleRelativeAddress(address)) || matchPattern(pattern, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleRelativeAddress returns the address relative to the module which the resource belongs to, e.g. `azapi_resource.vnet` for
+// `module.network.azapi_resource.vnet`
+func moduleRelativeAddress(address string) string {
+	parts := strings.Split(address, ".")
+	for len(parts) > 2 && parts[0] == "module" {
+		parts = parts[2:]
+	}
+	return strings.Join(parts, ".")
+}
+
+func matchPattern(pattern string, value string) bool {
+	if pattern == value {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// LoadIgnoreFile reads the patterns of resources to ignore from `filename`, lines starting with `#` are comments.
+// It returns an empty list if the file doesn't exist.
+func LoadIgnoreFile(filename string) ([]string, error) {
+	res := make([]string, 0)
+	// #nosec G304
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res, nil
+}
+
+// resourceTypeOfAddress returns the resource type of `address`, e.g. `azapi_resource` for `data.azapi_resource.test`
+func resourceTypeOfAddress(address string) string {
+	return strings.Split(strings.TrimPrefix(address, "data."), ".")[0]
+}
+
+// stringSliceFlag is a flag which can be specified multiple times
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+)
+
+func Test_AddressFilter(t *testing.T) {
+	testcases := []struct {
+		Filter       cmd.AddressFilter
+		Address      string
+		ResourceType string
+		Targeted     bool
+		Excluded     bool
+	}{
+		{
+			Filter:       cmd.AddressFilter{},
+			Address:      "azapi_resource.test",
+			ResourceType: "azapi_resource",
+			Targeted:     true,
+			Excluded:     false,
+		},
+		{
+			Filter:       cmd.AddressFilter{Targets: []string{"azapi_resource.*"}},
+			Address:      "azapi_update_resource.test",
+			ResourceType: "azapi_update_resource",
+			Targeted:     false,
+			Excluded:     false,
+		},
+		{
+			Filter:       cmd.AddressFilter{Targets: []string{"azurerm_storage_*"}, Excludes: []string{"azurerm_storage_account.logs"}},
+			Address:      "azurerm_storage_account.logs",
+			ResourceType: "azurerm_storage_account",
+			Targeted:     true,
+			Excluded:     true,
+		},
+		{
+			Filter:       cmd.AddressFilter{Excludes: []string{"module.network.*"}},
+			Address:      "module.network.azapi_resource.vnet",
+			ResourceType: "azapi_resource",
+			Targeted:     true,
+			Excluded:     true,
+		},
+		{
+			Filter:       cmd.AddressFilter{Targets: []string{"azapi_resource.*"}, Excludes: []string{"azapi_resource.subnet"}},
+			Address:      "module.network.module.subnet.azapi_resource.subnet",
+			ResourceType: "azapi_resource",
+			Targeted:     true,
+			Excluded:     true,
+		},
+		{
+			Filter:       cmd.AddressFilter{Targets: []string{"module.other.*"}},
+			Address:      "module.network.azapi_resource.vnet",
+			ResourceType: "azapi_resource",
+			Targeted:     false,
+			Excluded:     false,
+		},
+	}
+
+	for _, tc := range testcases {
+		if targeted := tc.Filter.IsTargeted(tc.Address, tc.ResourceType); targeted != tc.Targeted {
+			t.Fatalf("expect %s targeted to be %v, but got %v", tc.Address, tc.Targeted, targeted)
+		}
+		if excluded := tc.Filter.IsExcluded(tc.Address, tc.ResourceType); excluded != tc.Excluded {
+			t.Fatalf("expect %s excluded to be %v, but got %v", tc.Address, tc.Excluded, excluded)
+		}
+	}
+}
+
+func Test_LoadIgnoreFile(t *testing.T) {
+	dir := tempDir(t)
+	filename := filepath.Join(dir, "aztfmigrate.ignore")
+	err := os.WriteFile(filename, []byte(`
+# resources managed by another team
+azapi_resource.test
+
+azurerm_storage_*
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := cmd.LoadIgnoreFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"azapi_resource.test", "azurerm_storage_*"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Fatalf("expect %v, but got %v", expected, patterns)
+	}
+
+	patterns, err = cmd.LoadIgnoreFile(filepath.Join(dir, "not-exist"))
+	if err != nil || len(patterns) != 0 {
+		t.Fatalf("expect no patterns and no error, but got %v and %v", patterns, err)
+	}
+}
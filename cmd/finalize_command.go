@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/Azure/aztfmigrate/types"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/mitchellh/cli"
+)
+
+type FinalizeCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	workingDir string
+}
+
+func (c *FinalizeCommand) flags() *flag.FlagSet {
+	fs := defaultFlagSet("finalize")
+	fs.BoolVar(&c.Verbose, "v", false, "whether show terraform logs")
+	fs.StringVar(&c.workingDir, "working-dir", "", "path to Terraform configuration files")
+	fs.Usage = func() { c.Ui.Error(c.Help()) }
+	return fs
+}
+
+func (c *FinalizeCommand) Run(args []string) int {
+	f := c.flags()
+	if err := f.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s", err))
+		return 1
+	}
+	if c.workingDir == "" {
+		c.workingDir, _ = os.Getwd()
+	}
+
+	log.Printf("[INFO] initializing terraform...")
+	terraform, err := tf.NewTerraform(c.workingDir, c.Verbose)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := terraform.Init(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("[INFO] reading state...")
+	state, err := terraform.Show()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading state: %s", err))
+		return 1
+	}
+
+	changes, count, err := Finalize(c.workingDir, StateAddresses(state))
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error finalizing configuration: %s", err))
+		return 1
+	}
+	if len(changes) == 0 {
+		c.Ui.Output("No migration blocks can be removed, the state doesn't reflect the migration yet or the configuration is already finalized.")
+		return 0
+	}
+
+	files := make([]string, 0)
+	for file := range changes {
+		files = append(files, file)
+	}
+	snapshot, err := CreateSnapshot(c.workingDir, files, nil)
+	if err != nil {
+		log.Fatalf("creating snapshot: %+v", err)
+	}
+	log.Printf("[INFO] the configuration is backed up in snapshot %s, run `aztfmigrate rollback` to restore it", snapshot.Name)
+	for file, content := range changes {
+		if err := os.WriteFile(file, content, 0600); err != nil {
+			log.Printf("[ERROR] saving configuration %s: %+v", file, err)
+		}
+	}
+	c.Ui.Output(fmt.Sprintf("%d migration blocks are removed from %d files.", count, len(changes)))
+	return 0
+}
+
+func (c *FinalizeCommand) Help() string {
+	helpText := `
+Usage: aztfmigrate finalize
+` + c.Synopsis() + "\nThe `import`, `removed` and `moved` blocks and the commented-out original blocks generated by migration are removed once\n" +
+		"the state reflects the migration, which is checked by `terraform show -json`. Run it after the migrated configuration is applied.\n\n" + helpForFlags(c.flags())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *FinalizeCommand) Synopsis() string {
+	return "Remove the migration blocks from the Terraform configuration files after the migration is applied"
+}
+
+// StateAddresses returns the addresses of the resources in `state` without the instance keys
+func StateAddresses(state *tfjson.State) map[string]bool {
+	res := make(map[string]bool)
+	if state == nil || state.Values == nil {
+		return res
+	}
+	modules := []*tfjson.StateModule{state.Values.RootModule}
+	for len(modules) != 0 {
+		module := modules[0]
+		modules = modules[1:]
+		if module == nil {
+			continue
+		}
+		for _, resource := range module.Resources {
+			if resource != nil {
+				res[types.ResourceAddress(resource.Address)] = true
+			}
+		}
+		modules = append(modules, module.ChildModules...)
+	}
+	return res
+}
+
+// Finalize removes the migration blocks reflected by the state from the config files under the working directory, `addresses` are the
+// addresses of the resources in the state without the instance keys. It returns the new content of the changed files and the number of
+// the removed blocks, the files are not written.
+func Finalize(workingDirectory string, addresses map[string]bool) (map[string][]byte, int, error) {
+	res := make(map[string][]byte)
+	count := 0
+	err := filepath.WalkDir(workingDirectory, func(dir string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if dir != workingDirectory && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == tempFolderName) {
+			return filepath.SkipDir
+		}
+		for _, file := range helper.ListConfigFiles(helper.OsFileSystem{}, dir) {
+			filename := filepath.Join(dir, file)
+			// #nosec G304
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(string(src), types.MarkerGenerated) && !strings.Contains(string(src), types.MarkerOriginal) {
+				continue
+			}
+			content, n, err := types.FinalizeConfig(src, file, addresses)
+			if err != nil {
+				log.Printf("[WARN] finalizing %s: %+v", filename, err)
+				continue
+			}
+			if n != 0 {
+				res[filename] = content
+				count += n
+			}
+		}
+		return nil
+	})
+	return res, count, err
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Azure/aztfmigrate/azurerm/schema"
 	"github.com/Azure/aztfmigrate/helper"
 	"github.com/Azure/aztfmigrate/tf"
 	"github.com/Azure/aztfmigrate/types"
@@ -20,6 +21,39 @@ const filenameImport = "imports.tf"
 
 const tempFolderName = "aztfmigrate_temp"
 
+// filenameModuleMigrations is the file in the root module which contains the state update blocks for resources in child modules
+const filenameModuleMigrations = "migrations.tf"
+
+// filenameLockFile is the dependency lock file which records the selected provider versions
+const filenameLockFile = ".terraform.lock.hcl"
+
+// archiveFolderName is the folder in the working directory which the original blocks are archived to with -original=archive
+const archiveFolderName = "aztfmigrate_archive"
+
+// filenameModuleMigrationsJson is used instead of filenameModuleMigrations if the root module is written in JSON syntax
+const filenameModuleMigrationsJson = "migrations.tf.json"
+
+// moduleMigrationsFilename returns the file which contains the state update blocks for resources in child modules, it's the file specified
+// by -migrations-file if any. Otherwise, it's in JSON syntax if it exists or the root module only has config files in JSON syntax.
+func (c *MigrateCommand) moduleMigrationsFilename(fs helper.FileSystem, workingDirectory string) string {
+	if c.migrationsFile != "" {
+		return c.migrationsFile
+	}
+	if _, err := fs.ReadFile(filepath.Join(workingDirectory, filenameModuleMigrationsJson)); err == nil {
+		return filenameModuleMigrationsJson
+	}
+	files := helper.ListConfigFiles(fs, workingDirectory)
+	for _, file := range files {
+		if !helper.IsJsonConfig(file) {
+			return filenameModuleMigrations
+		}
+	}
+	if len(files) == 0 {
+		return filenameModuleMigrations
+	}
+	return filenameModuleMigrationsJson
+}
+
 type MigrateCommand struct {
 	Ui             cli.Ui
 	Verbose        bool
@@ -27,6 +61,22 @@ type MigrateCommand struct {
 	workingDir     string
 	varFile        string
 	TargetProvider string
+	typeMapFile    string
+	NonInteractive bool
+	planFile       string
+	planJson       string
+	verify         bool
+	dryRun         bool
+	patchFile      string
+	targets        stringSliceFlag
+	excludes       stringSliceFlag
+	Generator      string
+	AzapiVersion   int
+	StateMode      string
+	original       string
+	migrationsFile string
+	// stateOperations are the state changes performed by terraform CLI after the config is updated in cli state mode
+	stateOperations []StateOperation
 }
 
 func (c *MigrateCommand) flags() *flag.FlagSet {
@@ -36,6 +86,20 @@ func (c *MigrateCommand) flags() *flag.FlagSet {
 	fs.StringVar(&c.workingDir, "working-dir", "", "path to Terraform configuration files")
 	fs.StringVar(&c.varFile, "var-file", "", "path to the terraform variable file")
 	fs.StringVar(&c.TargetProvider, "to", "", "Specify the provider to migrate to. The allowed values are: azurerm and azapi. Default is azurerm.")
+	fs.StringVar(&c.typeMapFile, "type-map", "", "path to the file which maps terraform addresses or resource id patterns to azurerm resource types. Default is aztfmigrate.typemap in the working directory.")
+	fs.BoolVar(&c.NonInteractive, "non-interactive", false, "fail instead of asking for input when the azurerm resource type can't be resolved")
+	fs.StringVar(&c.planFile, "plan-file", "", "path to a plan file saved by terraform plan -out, it's used instead of running terraform plan")
+	fs.StringVar(&c.planJson, "plan-json", "", "path to the JSON output of terraform show -json, it's used instead of running terraform plan")
+	fs.BoolVar(&c.verify, "verify", false, "run terraform plan after migration and fail if there're changes other than import, remove and move")
+	fs.BoolVar(&c.dryRun, "dry-run", false, "print the changes as a unified diff instead of writing the configuration files")
+	fs.Var(&c.targets, "target", "glob pattern of the Terraform addresses or resource types to migrate, e.g. azapi_resource.*. It can be specified multiple times.")
+	fs.Var(&c.excludes, "exclude", "glob pattern of the Terraform addresses or resource types to skip, e.g. azurerm_storage_*. It can be specified multiple times.")
+	fs.StringVar(&c.patchFile, "patch", "", "path to the file to save the changes as a patch instead of writing the configuration files, it implies -dry-run")
+	fs.IntVar(&c.AzapiVersion, "azapi-version", types.AzapiVersion2, "the major version of azapi provider which the config is generated for when migrating to azapi. The allowed values are: 1 and 2. Default is 2.")
+	fs.StringVar(&c.StateMode, "state-mode", StateModeConfig, "how the state is updated. The allowed values are: config and cli. The config mode adds import, removed and moved blocks to the config, which requires terraform 1.5+. The cli mode runs terraform state rm and terraform import, the state is backed up before any change and restored on failure.")
+	fs.StringVar(&c.original, "original", types.OriginalComment, "how the original blocks are handled. The allowed values are: comment, archive and delete. The archive mode moves them to the aztfmigrate_archive directory, one file per address.")
+	fs.StringVar(&c.migrationsFile, "migrations-file", "", "name of the file in the module directory which the migrated blocks and the state update blocks are written to, e.g. migrations.tf. Default is the file of the original block.")
+	fs.StringVar(&c.Generator, "generator", tf.GeneratorTfadd, "the backend to generate the config of the migrated resources. The allowed values are: tfadd and terraform. The terraform backend runs terraform plan -generate-config-out, which follows the installed provider version.")
 
 	fs.Usage = func() { c.Ui.Error(c.Help()) }
 	return fs
@@ -63,6 +127,49 @@ func (c *MigrateCommand) Run(args []string) int {
 		c.Ui.Error("Invalid target provider. The allowed values are: azurerm and azapi.")
 		return 1
 	}
+	if c.planFile != "" && c.planJson != "" {
+		c.Ui.Error("Only one of -plan-file and -plan-json can be specified.")
+		return 1
+	}
+	if c.patchFile != "" {
+		c.dryRun = true
+	}
+	if c.Generator == "" {
+		c.Generator = tf.GeneratorTfadd
+	}
+	if c.Generator != tf.GeneratorTfadd && c.Generator != tf.GeneratorTerraform {
+		c.Ui.Error("Invalid generator. The allowed values are: tfadd and terraform.")
+		return 1
+	}
+	if c.AzapiVersion == 0 {
+		c.AzapiVersion = types.AzapiVersion2
+	}
+	if c.AzapiVersion != types.AzapiVersion1 && c.AzapiVersion != types.AzapiVersion2 {
+		c.Ui.Error("Invalid azapi version. The allowed values are: 1 and 2.")
+		return 1
+	}
+	if c.StateMode == "" {
+		c.StateMode = StateModeConfig
+	}
+	if c.StateMode != StateModeConfig && c.StateMode != StateModeCli {
+		c.Ui.Error("Invalid state mode. The allowed values are: config and cli.")
+		return 1
+	}
+	if c.original == "" {
+		c.original = types.OriginalComment
+	}
+	if c.original != types.OriginalComment && c.original != types.OriginalArchive && c.original != types.OriginalDelete {
+		c.Ui.Error("Invalid original mode. The allowed values are: comment, archive and delete.")
+		return 1
+	}
+	if c.migrationsFile != "" && (filepath.Base(c.migrationsFile) != c.migrationsFile || !strings.HasSuffix(c.migrationsFile, ".tf") && !helper.IsJsonConfig(c.migrationsFile)) {
+		c.Ui.Error("Invalid migrations file. It must be a file name with .tf or .tf.json extension.")
+		return 1
+	}
+	if c.dryRun && c.verify {
+		c.Ui.Error("-verify can't be used with -dry-run, because the configuration files are not changed.")
+		return 1
+	}
 
 	if c.workingDir == "" {
 		c.workingDir, _ = os.Getwd()
@@ -82,16 +189,28 @@ func (c *MigrateCommand) Run(args []string) int {
 		workingDir:     c.workingDir,
 		varFile:        c.varFile,
 		TargetProvider: c.TargetProvider,
+		typeMapFile:    c.typeMapFile,
+		NonInteractive: c.NonInteractive,
+		planFile:       c.planFile,
+		planJson:       c.planJson,
+		targets:        c.targets,
+		excludes:       c.excludes,
 	}
 	allResources := planCommand.Plan(terraform, false)
 	c.MigrateResources(terraform, allResources)
+	if c.verify {
+		return Verify(c.Ui, terraform, c.varFile)
+	}
 	return 0
 }
 
 func (c *MigrateCommand) Help() string {
 	helpText := `
-Usage: aztfmigrate migrate
-` + c.Synopsis() + "\nThe Terraform addresses listed in file `aztfmigrate.ignore` will be ignored during migration.\n\n" + helpForFlags(c.flags())
+Usage: aztfmigrate migrate [-plan-file=<file> | -plan-json=<file>] [-target=<pattern>]... [-exclude=<pattern>]... [-verify] [-dry-run] [-patch=<file>] [-generator=tfadd|terraform] [-azapi-version=1|2] [-state-mode=config|cli] [-original=comment|archive|delete] [-migrations-file=<file>]
+` + c.Synopsis() + "\nThe Terraform addresses or resource types matched by the glob patterns in file `aztfmigrate.ignore` will be ignored during migration.\n" +
+		"The azurerm resource types listed in file `aztfmigrate.typemap` will be used when there're multiple matches.\n" +
+		"With -to=azapi, the resources are moved by `moved` blocks if terraform is 1.8+ and azapi is v1.13.0+, otherwise they're removed and imported.\n" +
+		"The resources migrated to azurerm are always removed and imported, moving them from azapi is not possible because azurerm doesn't implement MoveResourceState.\n\n" + helpForFlags(c.flags())
 
 	return strings.TrimSpace(helpText)
 }
@@ -111,29 +230,255 @@ func (c *MigrateCommand) MigrateResources(terraform *tf.Terraform, resources []t
 	if err := os.MkdirAll(tempDir, 0750); err != nil {
 		log.Fatalf("creating temp workspace %q: %+v", tempDir, err)
 	}
-	if err := os.RemoveAll(path.Join(tempDir, "terraform.tfstate")); err != nil {
-		log.Printf("[WARN] removing temp workspace %q: %+v", tempDir, err)
-	}
 	defer func() {
 		err := os.RemoveAll(path.Join(tempDir, "terraform.tfstate"))
 		if err != nil {
 			log.Printf("[ERROR] removing temp workspace %q: %+v", tempDir, err)
 		}
 	}()
+	if err := copyLockFile(workingDirectory, tempDir); err != nil {
+		log.Printf("[WARN] copying the dependency lock file to temp workspace, the provider versions might differ from the locked ones: %+v", err)
+	}
 	tempTerraform, err := tf.NewTerraform(tempDir, c.Verbose)
 	if err != nil {
 		log.Fatal(err)
 	}
+	tempTerraform.Generator = c.Generator
+	loadProviderSchema(workingDirectory, tempTerraform, resources)
+	// the resources migrated to azapi are moved by `moved` blocks if the terraform and the azapi versions support it, otherwise they're
+	// removed and imported. The resources migrated to azurerm are always removed and imported.
+	movedSupported := c.StateMode != StateModeCli && terraform.SupportsMovedBlock(tf.ProviderAzapi)
+	for _, r := range resources {
+		switch resource := r.(type) {
+		case *types.AzurermResource:
+			resource.AzapiVersion = c.AzapiVersion
+			resource.MovedSupported = movedSupported
+		case *types.AzurermDataResource:
+			resource.AzapiVersion = c.AzapiVersion
+		}
+	}
+
+	// resources in different modules may have the same address, so they're migrated module by module
+	modules := make([]types.Module, 0)
+	moduleResources := make(map[string][]types.AzureResource)
+	for _, r := range resources {
+		module := r.GetModule()
+		if _, ok := moduleResources[module.Address]; !ok {
+			modules = append(modules, module)
+		}
+		moduleResources[module.Address] = append(moduleResources[module.Address], r)
+	}
+
+	// in dry-run mode, the changes are kept in memory and printed as a patch
+	var fs helper.FileSystem = helper.OsFileSystem{}
+	var memoryFs *helper.MemoryFileSystem
+	var snapshot *SnapshotManifest
+	if c.dryRun {
+		memoryFs = helper.NewMemoryFileSystem()
+		fs = memoryFs
+	} else {
+		snapshot = c.snapshot(workingDirectory, modules, resources)
+	}
+
+	c.stateOperations = make([]StateOperation, 0)
+	for _, module := range modules {
+		if !module.IsRoot() {
+			log.Printf("[INFO] migrating resources in %s...", module.Address)
+		}
+		c.migrateModuleResources(fs, workingDirectory, tempTerraform, module, moduleResources[module.Address])
+	}
+
+	if c.StateMode == StateModeCli {
+		if snapshot == nil {
+			for _, op := range c.stateOperations {
+				log.Printf("[INFO] skipping %s in dry-run mode", op)
+			}
+		} else if err := applyStateOperations(terraform, c.varFile, snapshotDirectory(workingDirectory, snapshot.Name), c.stateOperations); err != nil {
+			log.Fatalf("updating state: %+v, run `aztfmigrate rollback` to restore the configuration", err)
+		}
+	}
+
+	if memoryFs != nil {
+		patch, err := Patch(workingDirectory, memoryFs)
+		if err != nil {
+			log.Fatalf("generating patch: %+v", err)
+		}
+		if c.patchFile == "" {
+			c.Ui.Output(patch)
+			return
+		}
+		if err := os.WriteFile(c.patchFile, []byte(patch), 0600); err != nil {
+			log.Fatalf("writing patch to %s: %+v", c.patchFile, err)
+		}
+		log.Printf("[INFO] the changes are saved in %s, the working directory is not changed", c.patchFile)
+	}
+}
+
+// snapshot backs up the files which might be changed, so the migration can be rolled back
+func (c *MigrateCommand) snapshot(workingDirectory string, modules []types.Module, resources []types.AzureResource) *SnapshotManifest {
+	files := []string{filepath.Join(workingDirectory, c.moduleMigrationsFilename(helper.OsFileSystem{}, workingDirectory))}
+	dirs := []string{""}
+	for _, module := range modules {
+		dirs = append(dirs, module.Dir)
+	}
+	for _, dir := range dirs {
+		moduleDirectory := filepath.Join(workingDirectory, filepath.FromSlash(dir))
+		for _, file := range helper.ListConfigFiles(helper.OsFileSystem{}, moduleDirectory) {
+			files = append(files, filepath.Join(moduleDirectory, file))
+		}
+		if c.migrationsFile != "" {
+			files = append(files, filepath.Join(moduleDirectory, c.migrationsFile))
+		}
+	}
+	if c.original == types.OriginalArchive {
+		for _, r := range resources {
+			files = append(files, types.ArchivePath(filepath.Join(workingDirectory, archiveFolderName), r.GetModule().Qualify(r.OldAddress(nil))))
+		}
+	}
+	addresses := make([]string, 0)
+	for _, r := range resources {
+		addresses = append(addresses, r.GetModule().Qualify(r.OldAddress(nil)), r.GetModule().Qualify(r.NewAddress(nil)))
+	}
+	snapshot, err := CreateSnapshot(workingDirectory, files, addresses)
+	if err != nil {
+		log.Fatalf("creating snapshot: %+v", err)
+	}
+	log.Printf("[INFO] the configuration is backed up in snapshot %s, run `aztfmigrate rollback` to restore it", snapshot.Name)
+	return snapshot
+}
+
+// Patch returns the unified diff of the files written in `fs` against the files on disk, the file names are relative to the working directory
+func Patch(workingDirectory string, fs *helper.MemoryFileSystem) (string, error) {
+	patch := strings.Builder{}
+	for _, name := range fs.WrittenFiles() {
+		relativePath, err := filepath.Rel(workingDirectory, name)
+		if err != nil {
+			return "", err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		fromName := "a/" + relativePath
+		// #nosec G304
+		before, err := os.ReadFile(name)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return "", err
+			}
+			fromName = "/dev/null"
+		}
+		after, err := fs.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		patch.WriteString(helper.UnifiedDiff(fromName, "b/"+relativePath, string(before), string(after)))
+	}
+	return patch.String(), nil
+}
+
+// copyLockFile copies the dependency lock file of the working directory to the temp workspace, so the temp workspace installs the locked
+// provider versions. The temp workspace is initialized again if the lock file is changed.
+func copyLockFile(workingDirectory string, tempDir string) error {
+	// #nosec G304
+	data, err := os.ReadFile(filepath.Join(workingDirectory, filenameLockFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	// #nosec G304
+	if existing, err := os.ReadFile(filepath.Join(tempDir, filenameLockFile)); err == nil && string(existing) == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, filenameLockFile), data, 0600); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(tempDir, ".terraform"))
+}
+
+// loadProviderSchema loads the schema of the azurerm provider installed in the temp workspace, which is used to tune the generated config.
+// The embedded schema is used if it can't be loaded.
+func loadProviderSchema(workingDirectory string, tempTerraform *tf.Terraform, resources []types.AzureResource) {
+	schema.ResetProviderSchema()
+	toAzurerm := false
+	for _, r := range resources {
+		if r.TargetProvider() == "azurerm" {
+			toAzurerm = true
+			break
+		}
+	}
+	if !toAzurerm {
+		return
+	}
+
+	log.Printf("[INFO] loading azurerm provider schema...")
+	config := ProviderConfig(resources, helper.FindHclBlock(workingDirectory, "terraform", nil))
+	if err := os.WriteFile(filepath.Join(tempTerraform.GetWorkingDirectory(), filenameImport), []byte(config), 0600); err != nil {
+		log.Fatal(err)
+	}
+	pschs, err := tempTerraform.ProvidersSchema()
+	if err == nil {
+		err = schema.LoadProviderSchema(pschs)
+	}
+	if err != nil {
+		log.Printf("[WARN] loading the schema of the installed azurerm provider, the embedded schema of %s is used instead: %+v", schema.ProviderVersion, err)
+	}
+}
+
+// migrateModuleResources migrates `resources` which belong to `module`, the configuration in module's directory is updated,
+// and the state update blocks are placed in the root module because `import` blocks are only allowed in the root module.
+func (c *MigrateCommand) migrateModuleResources(fs helper.FileSystem, workingDirectory string, tempTerraform *tf.Terraform, module types.Module, resources []types.AzureResource) {
+	tempDir := tempTerraform.GetWorkingDirectory()
+	if err := os.RemoveAll(path.Join(tempDir, "terraform.tfstate")); err != nil {
+		log.Printf("[WARN] removing temp workspace %q: %+v", tempDir, err)
+	}
+
+	terraformBlock := helper.FindHclBlock(workingDirectory, "terraform", nil)
+	importTargets := make([]tf.ImportTarget, 0)
+	for _, r := range resources {
+		importTargets = append(importTargets, r.ImportTargets()...)
+	}
+	if len(importTargets) != 0 {
+		// the config of the batch import is generated by terraform, so it mustn't contain the resource blocks
+		if err := os.WriteFile(filepath.Join(tempDir, filenameImport), []byte(ProviderConfig(resources, terraformBlock)), 0600); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("[INFO] importing %d resources...", len(importTargets))
+		if err := tempTerraform.BatchImport(importTargets); err != nil {
+			log.Printf("[WARN] batch import failed, the resources which aren't imported will be imported one by one: %+v", err)
+		}
+	}
 
 	log.Printf("[INFO] generating import config...")
-	config := ImportConfig(resources, helper.FindHclBlock(workingDirectory, "terraform", nil))
-	if err = os.WriteFile(filepath.Join(tempDir, filenameImport), []byte(config), 0600); err != nil {
+	config := ImportConfig(resources, terraformBlock)
+	if tempTerraform.Generator == tf.GeneratorTerraform {
+		// terraform generates the config of the resources which are not in the config
+		config = ProviderConfig(resources, terraformBlock)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, filenameImport), []byte(config), 0600); err != nil {
 		log.Fatal(err)
 	}
 
+	moduleDirectory := filepath.Join(workingDirectory, filepath.FromSlash(module.Dir))
+	// the expressions in the original config, e.g. variables, locals and interpolations, are kept in the migrated config
+	for _, r := range resources {
+		if existingBlock, err := types.GetResourceBlock(fs, moduleDirectory, r.OldAddress(nil)); err == nil && existingBlock != nil {
+			types.AppendExpressionReferences(r, existingBlock)
+		}
+	}
+	// the references to the attributes of the resources migrated to azapi are replaced by the references to the exported response properties
+	for _, r := range resources {
+		if r.TargetProvider() != "azapi" {
+			continue
+		}
+		if references, err := types.ListAttributeReferences(fs, moduleDirectory, r.OldAddress(nil)); err == nil {
+			types.AppendAttributeOutputs(r, references)
+		}
+	}
+
+	types.ReadDataSources(tempTerraform, resources)
 	log.Printf("[INFO] migrating resources...")
 	for _, r := range resources {
-		log.Printf("[INFO] generating new config for resource %s...", r.OldAddress(nil))
+		log.Printf("[INFO] generating new config for resource %s...", module.Qualify(r.OldAddress(nil)))
 		if err := r.GenerateNewConfig(tempTerraform); err != nil {
 			log.Printf("[ERROR] %+v", err)
 		}
@@ -146,13 +491,16 @@ func (c *MigrateCommand) MigrateResources(terraform *tf.Terraform, resources []t
 			updateResources = append(updateResources, *updateResource)
 		}
 	}
-	if err := types.UpdateMigratedResourceBlock(workingDirectory, updateResources); err != nil {
+	if err := types.UpdateMigratedResourceBlock(fs, moduleDirectory, updateResources); err != nil {
 		log.Fatal(err)
 	}
 
 	// migrate depends_on, lifecycle, provisioner
 	for _, r := range resources {
-		if existingBlock, err := types.GetResourceBlock(workingDirectory, r.OldAddress(nil)); err == nil && existingBlock != nil {
+		if !r.IsMigrated() {
+			continue
+		}
+		if existingBlock, err := types.GetResourceBlock(fs, moduleDirectory, r.OldAddress(nil)); err == nil && existingBlock != nil {
 			migratedBlock := r.MigratedBlock()
 			if attr := existingBlock.Body().GetAttribute("depends_on"); attr != nil {
 				migratedBlock.Body().SetAttributeRaw("depends_on", attr.Expr().BuildTokens(nil))
@@ -168,13 +516,30 @@ func (c *MigrateCommand) MigrateResources(terraform *tf.Terraform, resources []t
 	// remove from config
 	for _, r := range resources {
 		if r.IsMigrated() {
-			log.Printf("[INFO] removing %s from config", r.OldAddress(nil))
+			log.Printf("[INFO] removing %s from config", module.Qualify(r.OldAddress(nil)))
 			stateUpdateBlocks := r.StateUpdateBlocks()
 			newBlocks := make([]*hclwrite.Block, 0)
-			newBlocks = append(newBlocks, stateUpdateBlocks...)
+			if c.StateMode == StateModeCli {
+				operations, err := StateOperations(stateUpdateBlocks)
+				if err != nil {
+					log.Fatalf("converting the state update blocks of %s: %+v", module.Qualify(r.OldAddress(nil)), err)
+				}
+				c.stateOperations = append(c.stateOperations, operations...)
+			} else if module.IsRoot() {
+				newBlocks = append(newBlocks, stateUpdateBlocks...)
+			} else if len(stateUpdateBlocks) != 0 {
+				if err := types.AppendBlocks(fs, workingDirectory, c.moduleMigrationsFilename(fs, workingDirectory), stateUpdateBlocks); err != nil {
+					log.Printf("[ERROR] error adding state update blocks for %s: %+v", module.Qualify(r.OldAddress(nil)), err)
+				}
+			}
 			newBlocks = append(newBlocks, r.MigratedBlock())
-			if err := types.ReplaceResourceBlock(workingDirectory, r.OldAddress(nil), newBlocks); err != nil {
-				log.Printf("[ERROR] error removing %s from state: %+v", r.OldAddress(nil), err)
+			options := types.ReplaceOptions{
+				Original:         c.original,
+				ArchiveDirectory: filepath.Join(workingDirectory, archiveFolderName),
+				Filename:         c.migrationsFile,
+			}
+			if err := types.ReplaceResourceBlock(fs, moduleDirectory, module, r.OldAddress(nil), newBlocks, options); err != nil {
+				log.Printf("[ERROR] error removing %s from state: %+v", module.Qualify(r.OldAddress(nil)), err)
 			}
 		}
 	}
@@ -186,12 +551,35 @@ func (c *MigrateCommand) MigrateResources(terraform *tf.Terraform, resources []t
 			outputs = append(outputs, r.Outputs()...)
 		}
 	}
-	if err := types.ReplaceGenericOutputs(workingDirectory, outputs); err != nil {
+	if err := types.ReplaceGenericOutputs(fs, moduleDirectory, outputs); err != nil {
 		log.Printf("[ERROR] replacing outputs: %+v", err)
 	}
+
+	addresses := make([]string, 0)
+	for _, r := range resources {
+		if r.IsMigrated() {
+			addresses = append(addresses, r.OldAddress(nil))
+		}
+	}
+	unresolvedReferences, err := types.ListUnresolvedReferences(fs, moduleDirectory, addresses)
+	if err != nil {
+		log.Printf("[ERROR] listing unresolved references: %+v", err)
+	}
+	for _, ref := range unresolvedReferences {
+		log.Printf("[WARN] reference to the migrated resource is not resolved, please update it manually: %s", filepath.Join(module.Dir, ref))
+	}
 }
 
 func ImportConfig(resources []types.AzureResource, terraformBlock *hclwrite.Block) string {
+	config := ProviderConfig(resources, terraformBlock) + "\n"
+	for _, r := range resources {
+		config += r.EmptyImportConfig()
+	}
+	return config
+}
+
+// ProviderConfig returns the terraform block and the provider blocks used in the temp workspace, the subscription id is detected from `resources`
+func ProviderConfig(resources []types.AzureResource, terraformBlock *hclwrite.Block) string {
 	config := `terraform {
   required_providers {
     azapi = {
@@ -205,9 +593,6 @@ func ImportConfig(resources []types.AzureResource, terraformBlock *hclwrite.Bloc
 		config = string(hclwrite.Format(newFile.Bytes()))
 	}
 
-	for _, r := range resources {
-		config += r.EmptyImportConfig()
-	}
 	subscriptionId := ""
 	for _, r := range resources {
 		switch resource := r.(type) {
@@ -229,6 +614,18 @@ func ImportConfig(resources []types.AzureResource, terraformBlock *hclwrite.Bloc
 					break
 				}
 			}
+		case *types.AzapiDataResource:
+			if strings.HasPrefix(resource.ResourceId, "/subscriptions/") {
+				subscriptionId = strings.Split(resource.ResourceId, "/")[2]
+			}
+		case *types.AzurermDataResource:
+			if strings.HasPrefix(resource.ResourceId, "/subscriptions/") {
+				subscriptionId = strings.Split(resource.ResourceId, "/")[2]
+			}
+		case *types.AzapiActionResource:
+			if strings.HasPrefix(resource.ResourceId, "/subscriptions/") {
+				subscriptionId = strings.Split(resource.ResourceId, "/")[2]
+			}
 		}
 		if subscriptionId != "" {
 			break
@@ -58,7 +58,19 @@ func TestMigrate_metaArgumentsAzureRM(t *testing.T) {
 	migrateTestCase(t, metaArgumentsAzureRM(), "azapi")
 }
 
+func TestMigrate_basicTerraformGenerator(t *testing.T) {
+	migrateTestCaseWithGenerator(t, basic(), "azurerm", tf.GeneratorTerraform)
+}
+
+func TestMigrate_foreachTerraformGenerator(t *testing.T) {
+	migrateTestCaseWithGenerator(t, foreach(), "azurerm", tf.GeneratorTerraform)
+}
+
 func migrateTestCase(t *testing.T, content string, targetProvider string, ignore ...string) {
+	migrateTestCaseWithGenerator(t, content, targetProvider, tf.GeneratorTfadd, ignore...)
+}
+
+func migrateTestCaseWithGenerator(t *testing.T, content string, targetProvider string, generator string, ignore ...string) {
 	if len(os.Getenv("TF_ACC")) == 0 {
 		t.Skipf("Set `TF_ACC=true` to enable this test")
 	}
@@ -145,7 +157,7 @@ provider "azurerm" {
 		}
 	}
 
-	migrateCommand := cmd.MigrateCommand{Ui: ui, TargetProvider: targetProvider}
+	migrateCommand := cmd.MigrateCommand{Ui: ui, TargetProvider: targetProvider, Generator: generator}
 	migrateCommand.MigrateResources(terraform, migrateResources)
 
 	// check generic resources are migrated
@@ -0,0 +1,276 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_Patch(t *testing.T) {
+	dir := tempDir(t)
+	mainFile := filepath.Join(dir, "main.tf")
+	original := "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\nline9\nline10\n"
+	if err := os.WriteFile(mainFile, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := helper.NewMemoryFileSystem()
+	if err := fs.WriteFile(mainFile, []byte("line1\nline2\nline3\nline4\nline5 updated\nline6\nline7\nline8\nline9\nline10\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteFile(filepath.Join(dir, "migrations.tf"), []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := cmd.Patch(dir, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `--- a/main.tf
++++ b/main.tf
+@@ -2,7 +2,7 @@
+ line2
+ line3
+ line4
+-line5
++line5 updated
+ line6
+ line7
+ line8
+--- /dev/null
++++ b/migrations.tf
+@@ -0,0 +1,1 @@
++new
+`
+	if patch != expected {
+		t.Fatalf("expect patch:\n%s\nbut got:\n%s", expected, patch)
+	}
+
+	data, err := os.ReadFile(mainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Fatal("expect the file on disk to be unchanged")
+	}
+}
+
+func Test_PatchNoNewlineAtEndOfFile(t *testing.T) {
+	dir := tempDir(t)
+	mainFile := filepath.Join(dir, "main.tf")
+	if err := os.WriteFile(mainFile, []byte("line1\nline2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := helper.NewMemoryFileSystem()
+	if err := fs.WriteFile(mainFile, []byte("line1\nline2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := cmd.Patch(dir, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `--- a/main.tf
++++ b/main.tf
+@@ -1,2 +1,2 @@
+ line1
+-line2
+\ No newline at end of file
++line2
+`
+	if patch != expected {
+		t.Fatalf("expect patch:\n%s\nbut got:\n%s", expected, patch)
+	}
+}
+
+func Test_PatchEmptyFile(t *testing.T) {
+	testcases := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "both empty",
+			before:   "",
+			after:    "",
+			expected: "",
+		},
+		{
+			name:   "empty before",
+			before: "",
+			after:  "line1\nline2\n",
+			expected: `--- a/main.tf
++++ b/main.tf
+@@ -0,0 +1,2 @@
++line1
++line2
+`,
+		},
+		{
+			name:   "empty after",
+			before: "line1\nline2\n",
+			after:  "",
+			expected: `--- a/main.tf
++++ b/main.tf
+@@ -1,2 +0,0 @@
+-line1
+-line2
+`,
+		},
+		{
+			name:   "empty before and no newline after",
+			before: "",
+			after:  "line1",
+			expected: `--- a/main.tf
++++ b/main.tf
+@@ -0,0 +1,1 @@
++line1
+\ No newline at end of file
+`,
+		},
+	}
+	for _, testcase := range testcases {
+		dir := tempDir(t)
+		mainFile := filepath.Join(dir, "main.tf")
+		if err := os.WriteFile(mainFile, []byte(testcase.before), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fs := helper.NewMemoryFileSystem()
+		if err := fs.WriteFile(mainFile, []byte(testcase.after)); err != nil {
+			t.Fatal(err)
+		}
+		patch, err := cmd.Patch(dir, fs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if patch != testcase.expected {
+			t.Errorf("%s: expect patch:\n%s\nbut got:\n%s", testcase.name, testcase.expected, patch)
+		}
+	}
+}
+
+func Test_PatchNoNewlineAtEndOfBothFiles(t *testing.T) {
+	dir := tempDir(t)
+	mainFile := filepath.Join(dir, "main.tf")
+	if err := os.WriteFile(mainFile, []byte("line1\nline2\nline3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := helper.NewMemoryFileSystem()
+	if err := fs.WriteFile(mainFile, []byte("line1\nline2 updated\nline3")); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := cmd.Patch(dir, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `--- a/main.tf
++++ b/main.tf
+@@ -1,3 +1,3 @@
+ line1
+-line2
++line2 updated
+ line3
+\ No newline at end of file
+`
+	if patch != expected {
+		t.Fatalf("expect patch:\n%s\nbut got:\n%s", expected, patch)
+	}
+}
+
+func Test_PatchMatchesMigrationWithMigrationsFile(t *testing.T) {
+	config := `resource "azapi_resource" "a" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "a"
+}
+
+resource "azapi_resource" "b" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "b"
+}
+
+output "a_id" {
+  value = azapi_resource.a.id
+}
+`
+	// migrate writes the migrated blocks to migrations.tf, which doesn't exist before migration. The migrated block of azapi_resource.b
+	// references azapi_resource.a, so migrations.tf must be revisited when the references are replaced.
+	migrate := func(fs helper.FileSystem, dir string) []string {
+		options := types.ReplaceOptions{Original: types.OriginalDelete, Filename: "migrations.tf"}
+		a := hclwrite.NewBlock("resource", []string{"azurerm_resource_group", "a"})
+		a.Body().SetAttributeRaw("name", helper.GetTokensForExpression(`"a"`))
+		if err := types.ReplaceResourceBlock(fs, dir, types.Module{}, "azapi_resource.a", []*hclwrite.Block{a}, options); err != nil {
+			t.Fatal(err)
+		}
+		b := hclwrite.NewBlock("resource", []string{"azurerm_resource_group", "b"})
+		b.Body().SetAttributeRaw("name", helper.GetTokensForExpression(`"b"`))
+		b.Body().SetAttributeRaw("tags", helper.GetTokensForExpression(`{
+  parent = azapi_resource.a.name
+}`))
+		if err := types.ReplaceResourceBlock(fs, dir, types.Module{}, "azapi_resource.b", []*hclwrite.Block{b}, options); err != nil {
+			t.Fatal(err)
+		}
+		outputs := []types.Output{
+			{OldName: "azapi_resource.a", NewName: "azurerm_resource_group.a"},
+			{OldName: "azapi_resource.b", NewName: "azurerm_resource_group.b"},
+		}
+		if err := types.ReplaceGenericOutputs(fs, dir, outputs); err != nil {
+			t.Fatal(err)
+		}
+		unresolved, err := types.ListUnresolvedReferences(fs, dir, []string{"azapi_resource.a", "azapi_resource.b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return unresolved
+	}
+
+	realDir := filepath.Join(tempDir(t), "real")
+	dryRunDir := filepath.Join(tempDir(t), "dry-run")
+	for _, dir := range []string{realDir, dryRunDir} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if unresolved := migrate(helper.OsFileSystem{}, realDir); len(unresolved) != 0 {
+		t.Fatalf("expect no unresolved references, but got %v", unresolved)
+	}
+	dryRunFs := helper.NewMemoryFileSystem()
+	if unresolved := migrate(dryRunFs, dryRunDir); len(unresolved) != 0 {
+		t.Fatalf("expect no unresolved references in dry-run, but got %v", unresolved)
+	}
+
+	// the patch of the real run is built from the migrated files on disk
+	realFs := helper.NewMemoryFileSystem()
+	for _, file := range helper.ListConfigFiles(helper.OsFileSystem{}, realDir) {
+		data, err := os.ReadFile(filepath.Join(realDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := realFs.WriteFile(filepath.Join(dryRunDir, file), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := cmd.Patch(dryRunDir, realFs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := cmd.Patch(dryRunDir, dryRunFs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Fatalf("expect the dry-run patch:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/Azure/aztfmigrate/azurerm"
 	"github.com/Azure/aztfmigrate/tf"
 	"github.com/Azure/aztfmigrate/types"
+	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/mitchellh/cli"
 )
 
@@ -22,6 +25,14 @@ type PlanCommand struct {
 	workingDir     string
 	varFile        string
 	TargetProvider string
+	jsonOutput     bool
+	outFile        string
+	typeMapFile    string
+	NonInteractive bool
+	planFile       string
+	planJson       string
+	targets        stringSliceFlag
+	excludes       stringSliceFlag
 }
 
 func (c *PlanCommand) flags() *flag.FlagSet {
@@ -31,6 +42,14 @@ func (c *PlanCommand) flags() *flag.FlagSet {
 	fs.StringVar(&c.workingDir, "working-dir", "", "path to Terraform configuration files")
 	fs.StringVar(&c.varFile, "var-file", "", "path to the terraform variable file")
 	fs.StringVar(&c.TargetProvider, "to", "", "Specify the provider to migrate to. The allowed values are: azurerm and azapi. Default is azurerm.")
+	fs.BoolVar(&c.jsonOutput, "json", false, "output the plan as a JSON object")
+	fs.StringVar(&c.outFile, "out", "", "path to the file to save the plan as a JSON object")
+	fs.StringVar(&c.typeMapFile, "type-map", "", "path to the file which maps terraform addresses or resource id patterns to azurerm resource types. Default is aztfmigrate.typemap in the working directory.")
+	fs.BoolVar(&c.NonInteractive, "non-interactive", false, "fail instead of asking for input when the azurerm resource type can't be resolved")
+	fs.StringVar(&c.planFile, "plan-file", "", "path to a plan file saved by terraform plan -out, it's used instead of running terraform plan")
+	fs.StringVar(&c.planJson, "plan-json", "", "path to the JSON output of terraform show -json, it's used instead of running terraform plan")
+	fs.Var(&c.targets, "target", "glob pattern of the Terraform addresses or resource types to migrate, e.g. azapi_resource.*. It can be specified multiple times.")
+	fs.Var(&c.excludes, "exclude", "glob pattern of the Terraform addresses or resource types to skip, e.g. azurerm_storage_*. It can be specified multiple times.")
 	fs.Usage = func() { c.Ui.Error(c.Help()) }
 	return fs
 }
@@ -51,24 +70,53 @@ func (c *PlanCommand) Run(args []string) int {
 		c.Ui.Error("Invalid target provider. The allowed values are: azurerm and azapi.")
 		return 1
 	}
+	if c.planFile != "" && c.planJson != "" {
+		c.Ui.Error("Only one of -plan-file and -plan-json can be specified.")
+		return 1
+	}
 	log.Printf("[INFO] target provider: %s", c.TargetProvider)
 
-	log.Printf("[INFO] initializing terraform...")
 	if c.workingDir == "" {
 		c.workingDir, _ = os.Getwd()
 	}
-	terraform, err := tf.NewTerraform(c.workingDir, c.Verbose)
-	if err != nil {
-		log.Fatal(err)
+	var report *PlanReport
+	if c.planJson != "" {
+		// the plan is already rendered, so terraform is not needed
+		p, err := tf.ReadPlanJson(c.planJson)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, report = c.PlanResources(c.workingDir, p, true)
+	} else {
+		log.Printf("[INFO] initializing terraform...")
+		terraform, err := tf.NewTerraform(c.workingDir, c.Verbose)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, report = c.PlanWithReport(terraform, true)
+	}
+	if c.jsonOutput {
+		data, err := report.MarshalIndent()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error marshalling JSON: %s", err))
+			return 1
+		}
+		c.Ui.Output(string(data))
+	}
+	if c.outFile != "" {
+		if err := report.WriteFile(c.outFile); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing plan to %s: %s", c.outFile, err))
+			return 1
+		}
 	}
-	c.Plan(terraform, true)
 	return 0
 }
 
 func (c *PlanCommand) Help() string {
 	helpText := `
-Usage: aztfmigrate plan
-` + c.Synopsis() + "\nThe Terraform addresses listed in file `aztfmigrate.ignore` will be ignored during migration.\n\n" + helpForFlags(c.flags())
+Usage: aztfmigrate plan [-json] [-out=<file>] [-plan-file=<file> | -plan-json=<file>] [-target=<pattern>]... [-exclude=<pattern>]...
+` + c.Synopsis() + "\nThe Terraform addresses or resource types matched by the glob patterns in file `aztfmigrate.ignore` will be ignored during migration.\n" +
+		"The azurerm resource types listed in file `aztfmigrate.typemap` will be used when there're multiple matches.\n\n" + helpForFlags(c.flags())
 
 	return strings.TrimSpace(helpText)
 }
@@ -78,96 +126,191 @@ func (c *PlanCommand) Synopsis() string {
 }
 
 func (c *PlanCommand) Plan(terraform *tf.Terraform, isPlanOnly bool) []types.AzureResource {
-	// get azapi resource from state
-	log.Printf("[INFO] running terraform plan...")
-	p, err := terraform.Plan(&c.varFile)
+	res, _ := c.PlanWithReport(terraform, isPlanOnly)
+	return res
+}
+
+// PlanWithReport returns the resources to migrate and a report which describes how each resource will be handled
+func (c *PlanCommand) PlanWithReport(terraform *tf.Terraform, isPlanOnly bool) ([]types.AzureResource, *PlanReport) {
+	p, err := c.loadPlan(terraform)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return c.PlanResources(terraform.GetWorkingDirectory(), p, isPlanOnly)
+}
 
+// loadPlan reads the saved plan if it's specified, otherwise runs terraform plan
+func (c *PlanCommand) loadPlan(terraform *tf.Terraform) (*tfjson.Plan, error) {
+	switch {
+	case c.planJson != "":
+		log.Printf("[INFO] reading plan from %s...", c.planJson)
+		return tf.ReadPlanJson(c.planJson)
+	case c.planFile != "":
+		log.Printf("[INFO] reading plan from %s...", c.planFile)
+		// terraform show runs in the working directory, so the relative path must be resolved first
+		planFile, err := filepath.Abs(c.planFile)
+		if err != nil {
+			return nil, err
+		}
+		return terraform.ShowPlanFile(planFile)
+	default:
+		// get azapi resource from state
+		log.Printf("[INFO] running terraform plan...")
+		return terraform.Plan(&c.varFile)
+	}
+}
+
+// PlanResources returns the resources in plan `p` to migrate and a report which describes how each resource will be handled,
+// the ignore file and type map file are read from `workingDirectory`
+func (c *PlanCommand) PlanResources(workingDirectory string, p *tfjson.Plan, isPlanOnly bool) ([]types.AzureResource, *PlanReport) {
 	migrationMessage := "The following resources will be migrated:\n"
 	unsupportedMessage := "The following resources can't be migrated:\n"
 	ignoreMessage := "The following resources will be ignored in migration:\n"
-	ignoreSet := make(map[string]bool)
-	if file, err := os.ReadFile(path.Join(terraform.GetWorkingDirectory(), "aztfmigrate.ignore")); err == nil {
-		lines := strings.Split(string(file), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if len(line) == 0 {
-				continue
-			}
-			ignoreSet[line] = true
-			ignoreMessage += fmt.Sprintf("\t%s\n", line)
-		}
+	ignorePatterns, err := LoadIgnoreFile(path.Join(workingDirectory, filenameIgnore))
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to load ignore file: %w", err))
+	}
+	filter := AddressFilter{
+		Targets:  c.targets,
+		Excludes: append(ignorePatterns, c.excludes...),
+	}
+
+	typeMapFile := c.typeMapFile
+	if typeMapFile == "" {
+		typeMapFile = path.Join(workingDirectory, filenameTypeMap)
 	}
+	typeMap, err := LoadTypeMap(typeMapFile)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to load type map %s: %w", typeMapFile, err))
+	}
+	unresolved := make([]string, 0)
 
 	res := make([]types.AzureResource, 0)
+	report := &PlanReport{
+		TargetProvider: c.TargetProvider,
+		Resources:      make([]PlanReportResource, 0),
+	}
 
 	for _, item := range types.ListResourcesFromPlan(p) {
 		if item.TargetProvider() != c.TargetProvider {
 			continue
 		}
-		if ignoreSet[item.OldAddress(nil)] {
+		module := item.GetModule()
+		oldAddress := module.Qualify(item.OldAddress(nil))
+		reportItem := newPlanReportResource(item, PlanStatusMigrate)
+		resourceType := resourceTypeOfAddress(item.OldAddress(nil))
+		if !filter.IsTargeted(oldAddress, resourceType) {
+			reportItem.Status = PlanStatusIgnored
+			reportItem.Reason = "not matched by -target"
+			report.Resources = append(report.Resources, reportItem)
+			continue
+		}
+		if filter.IsExcluded(oldAddress, resourceType) {
+			ignoreMessage += fmt.Sprintf("\t%s\n", oldAddress)
+			reportItem.Status = PlanStatusIgnored
+			report.Resources = append(report.Resources, reportItem)
 			continue
 		}
 		if err := item.CoverageCheck(c.Strict); err != nil {
 			unsupportedMessage += fmt.Sprintf("\t%s\n", err)
+			reportItem.setCoverageError(err)
+			report.Resources = append(report.Resources, reportItem)
 			continue
 		}
 
-		switch resource := item.(type) {
-		case *types.AzapiResource:
-			if len(resource.Instances) == 0 {
-				continue
-			}
-			resourceId := resource.Instances[0].ResourceId
+		// resolveAzurermType resolves the azurerm resource type of the azapi resource whose id is `resourceId`, sets it by `setResourceType`
+		// and reports the resolved address, or the candidate resource types if it's not resolved
+		resolveAzurermType := func(resourceId string, setResourceType func(resourceType string)) {
 			resourceTypes, exact, err := azurerm.GetAzureRMResourceType(resourceId)
 			if err != nil {
 				log.Fatal(fmt.Errorf("failed to get resource type for %s: %w", resourceId, err))
 			}
-			if exact {
-				resource.ResourceType = resourceTypes[0]
-			} else if !isPlanOnly {
-				resource.ResourceType = c.getUserInputResourceType(resourceId, resourceTypes)
-			}
+			resourceType := c.resolveResourceType(typeMap, oldAddress, resourceId, resourceTypes, exact, isPlanOnly)
+			setResourceType(resourceType)
 
-			if resource.ResourceType != "" {
-				migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", resource.OldAddress(nil), resource.NewAddress(nil))
+			if resourceType != "" {
+				migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", oldAddress, module.Qualify(item.NewAddress(nil)))
+				reportItem.NewAddress = module.Qualify(item.NewAddress(nil))
 			} else {
-				migrationMessage += fmt.Sprintf("\t%s will be replaced with %v\n", resource.OldAddress(nil), strings.Join(resourceTypes, ", "))
-			}
-			res = append(res, resource)
-
-		case *types.AzapiUpdateResource:
-			resourceTypes, exact, err := azurerm.GetAzureRMResourceType(resource.Id)
-			if err != nil {
-				log.Fatal(fmt.Errorf("failed to get resource type for %s: %w", resource.Id, err))
+				migrationMessage += fmt.Sprintf("\t%s will be replaced with %v\n", oldAddress, strings.Join(resourceTypes, ", "))
+				unresolved = append(unresolved, oldAddress)
 			}
+			reportItem.CandidateResourceTypes = resourceTypes
+			reportItem.Exact = exact
+		}
 
-			if exact {
-				resource.ResourceType = resourceTypes[0]
-			} else if !isPlanOnly {
-				resource.ResourceType = c.getUserInputResourceType(resource.Id, resourceTypes)
+		switch resource := item.(type) {
+		case *types.AzapiResource:
+			if len(resource.Instances) == 0 {
+				continue
 			}
+			resolveAzurermType(resource.Instances[0].ResourceId, func(resourceType string) { resource.ResourceType = resourceType })
+			res = append(res, resource)
 
-			if resource.ResourceType != "" {
-				migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", resource.OldAddress(nil), resource.NewAddress(nil))
-			} else {
-				migrationMessage += fmt.Sprintf("\t%s will be replaced with %v\n", resource.OldAddress(nil), strings.Join(resourceTypes, ", "))
-			}
+		case *types.AzapiUpdateResource:
+			resolveAzurermType(resource.Id, func(resourceType string) { resource.ResourceType = resourceType })
 			res = append(res, resource)
 
 		case *types.AzurermResource:
 			if len(resource.Instances) == 0 {
 				continue
 			}
-			migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", resource.OldAddress(nil), resource.NewAddress(nil))
+			migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", oldAddress, module.Qualify(resource.NewAddress(nil)))
+			reportItem.NewAddress = module.Qualify(resource.NewAddress(nil))
+			reportItem.CandidateResourceTypes = []string{"azapi_resource"}
+			reportItem.Exact = true
+			res = append(res, resource)
+
+		case *types.AzapiDataResource:
+			resolveAzurermType(resource.ResourceId, func(resourceType string) { resource.ResourceType = resourceType })
+			res = append(res, resource)
+
+		case *types.AzapiActionResource:
+			resolveAzurermType(resource.ResourceId, func(resourceType string) { resource.ResourceType = resourceType })
+			res = append(res, resource)
+
+		case *types.AzapiDataPlaneResource:
+			migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", oldAddress, module.Qualify(resource.NewAddress(nil)))
+			reportItem.NewAddress = module.Qualify(resource.NewAddress(nil))
+			reportItem.CandidateResourceTypes = []string{resource.ResourceType}
+			reportItem.Exact = true
+			res = append(res, resource)
+
+		case *types.AzurermDataResource:
+			migrationMessage += fmt.Sprintf("\t%s will be replaced with %s\n", oldAddress, module.Qualify(resource.NewAddress(nil)))
+			reportItem.NewAddress = module.Qualify(resource.NewAddress(nil))
+			reportItem.CandidateResourceTypes = []string{"azapi_resource"}
+			reportItem.Exact = true
 			res = append(res, resource)
 		}
+		report.Resources = append(report.Resources, reportItem)
 	}
 
 	log.Printf("[INFO]\n\nThe tool will perform the following actions:\n\n%s\n%s\n%s\n", migrationMessage, unsupportedMessage, ignoreMessage)
-	return res
+	if !isPlanOnly && c.NonInteractive && len(unresolved) != 0 {
+		log.Fatalf("[ERROR] couldn't resolve the azurerm resource type for the following resources, please specify them in the type map file %s:\n\t%s", typeMapFile, strings.Join(unresolved, "\n\t"))
+	}
+	return res, report
+}
+
+// resolveResourceType returns the azurerm resource type for the resource, it's resolved in the following order:
+// 1. the only match of the resource id
+// 2. the resource type specified in the type map
+// 3. the user input, it's skipped in plan only mode or non-interactive mode
+func (c *PlanCommand) resolveResourceType(typeMap *TypeMap, address string, resourceId string, values []string, exact bool, isPlanOnly bool) string {
+	if exact {
+		return values[0]
+	}
+	if resourceType := typeMap.Lookup(address, resourceId); resourceType != "" {
+		if len(values) == 0 || slices.Contains(values, resourceType) {
+			return resourceType
+		}
+		log.Printf("[WARN] resource type %s specified in the type map is not valid for %s, possible values are [%s]", resourceType, address, strings.Join(values, ", "))
+	}
+	if isPlanOnly || c.NonInteractive {
+		return ""
+	}
+	return c.getUserInputResourceType(resourceId, values)
 }
 
 func (c *PlanCommand) getUserInputResourceType(resourceId string, values []string) string {
@@ -92,3 +92,79 @@ func planTestCase(t *testing.T, content string, expectMigratedAddresses []string
 		}
 	}
 }
+
+func TestPlan_planJson(t *testing.T) {
+	dir := tempDir(t)
+	planJson := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "azurerm_resource_group.test",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "test",
+      "provider_name": "registry.terraform.io/hashicorp/azurerm",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg",
+          "name": "rg",
+          "location": "westeurope"
+        }
+      }
+    },
+    {
+      "address": "azurerm_resource_group.ignored",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "ignored",
+      "provider_name": "registry.terraform.io/hashicorp/azurerm",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg2",
+          "name": "rg2",
+          "location": "westeurope"
+        }
+      }
+    }
+  ],
+  "configuration": {
+    "root_module": {}
+  }
+}`
+	planFile := filepath.Join(dir, "plan.json")
+	if err := os.WriteFile(planFile, []byte(planJson), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "aztfmigrate.ignore"), []byte("azurerm_resource_group.ignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := tf.ReadPlanJson(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planCommand := cmd.PlanCommand{Ui: cli.NewMockUi(), TargetProvider: "azapi"}
+	resources, report := planCommand.PlanResources(dir, p, true)
+	if len(resources) != 1 || resources[0].OldAddress(nil) != "azurerm_resource_group.test" {
+		t.Fatalf("expect azurerm_resource_group.test to be migrated, but got %d resources", len(resources))
+	}
+	if len(report.Resources) != 2 {
+		t.Fatalf("expect 2 resources in the report, but got %d", len(report.Resources))
+	}
+	for _, r := range report.Resources {
+		switch r.OldAddress {
+		case "azurerm_resource_group.test":
+			if r.Status != cmd.PlanStatusMigrate || r.NewAddress != "azapi_resource.resourceGroup_test" {
+				t.Fatalf("expect %s to be migrated to azapi_resource.resourceGroup_test, but got %s %s", r.OldAddress, r.Status, r.NewAddress)
+			}
+		case "azurerm_resource_group.ignored":
+			if r.Status != cmd.PlanStatusIgnored {
+				t.Fatalf("expect %s to be ignored, but got %s", r.OldAddress, r.Status)
+			}
+		default:
+			t.Fatalf("unexpected resource %s in the report", r.OldAddress)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/Azure/aztfmigrate/azurerm/coverage"
+	"github.com/Azure/aztfmigrate/types"
+)
+
+const (
+	PlanStatusMigrate     = "migrate"
+	PlanStatusUnsupported = "unsupported"
+	PlanStatusIgnored     = "ignored"
+)
+
+// PlanReport is the machine-readable output of the `plan` command
+type PlanReport struct {
+	TargetProvider string               `json:"target_provider"`
+	Resources      []PlanReportResource `json:"resources"`
+}
+
+type PlanReportResource struct {
+	OldAddress string `json:"old_address"`
+	NewAddress string `json:"new_address,omitempty"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+
+	// CandidateResourceTypes are the resource types which the resource can be migrated to, Exact is true when there's only one match
+	CandidateResourceTypes []string `json:"candidate_resource_types,omitempty"`
+	Exact                  bool     `json:"exact"`
+
+	UncoveredInputProperties  []string `json:"uncovered_input_properties,omitempty"`
+	UncoveredOutputProperties []string `json:"uncovered_output_properties,omitempty"`
+
+	ApiVersion        string `json:"api_version,omitempty"`
+	AzurermApiVersion string `json:"azurerm_api_version,omitempty"`
+	ApiVersionMatched *bool  `json:"api_version_matched,omitempty"`
+}
+
+// newPlanReportResource builds a report entry for `item` with the api-version comparison filled in
+func newPlanReportResource(item types.AzureResource, status string) PlanReportResource {
+	res := PlanReportResource{
+		OldAddress: item.GetModule().Qualify(item.OldAddress(nil)),
+		Status:     status,
+	}
+
+	resourceId := ""
+	switch resource := item.(type) {
+	case *types.AzapiResource:
+		if len(resource.Instances) != 0 {
+			resourceId = resource.Instances[0].ResourceId
+			res.ApiVersion = resource.Instances[0].ApiVersion
+		}
+	case *types.AzapiUpdateResource:
+		resourceId = resource.Id
+		res.ApiVersion = resource.ApiVersion
+	case *types.AzapiDataResource:
+		resourceId = resource.ResourceId
+		res.ApiVersion = resource.ApiVersion
+	case *types.AzapiActionResource:
+		resourceId = resource.ResourceId
+		res.ApiVersion = resource.ApiVersion
+	}
+	if resourceId != "" {
+		if idPattern, err := types.GetIdPattern(resourceId); err == nil {
+			res.AzurermApiVersion = coverage.GetApiVersion(idPattern)
+		}
+		matched := res.ApiVersion == res.AzurermApiVersion
+		res.ApiVersionMatched = &matched
+	}
+	return res
+}
+
+// setCoverageError records the reason why the resource can't be migrated
+func (r *PlanReportResource) setCoverageError(err error) {
+	r.Status = PlanStatusUnsupported
+	r.Reason = err.Error()
+	var coverageErr *types.CoverageError
+	if errors.As(err, &coverageErr) {
+		r.UncoveredInputProperties = coverageErr.UncoveredInputProperties
+		r.UncoveredOutputProperties = coverageErr.UncoveredOutputProperties
+	}
+}
+
+func (r *PlanReport) MarshalIndent() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+func (r *PlanReport) WriteFile(filename string) error {
+	data, err := r.MarshalIndent()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/mitchellh/cli"
+)
+
+type RollbackCommand struct {
+	Ui          cli.Ui
+	Verbose     bool
+	workingDir  string
+	snapshot    string
+	list        bool
+	autoApprove bool
+}
+
+func (c *RollbackCommand) flags() *flag.FlagSet {
+	fs := defaultFlagSet("rollback")
+	fs.StringVar(&c.workingDir, "working-dir", "", "path to Terraform configuration files")
+	fs.StringVar(&c.snapshot, "snapshot", "", "name of the snapshot to restore. Default is the latest one.")
+	fs.BoolVar(&c.list, "list", false, "list the snapshots instead of restoring one")
+	fs.BoolVar(&c.autoApprove, "auto-approve", false, "restore the state backed up in the snapshot without asking for confirmation")
+	fs.BoolVar(&c.Verbose, "v", false, "whether show terraform logs")
+	fs.Usage = func() { c.Ui.Error(c.Help()) }
+	return fs
+}
+
+func (c *RollbackCommand) Run(args []string) int {
+	f := c.flags()
+	if err := f.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s", err))
+		return 1
+	}
+	if c.workingDir == "" {
+		c.workingDir, _ = os.Getwd()
+	}
+
+	if c.list {
+		snapshots, err := ListSnapshots(c.workingDir)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error listing snapshots: %s", err))
+			return 1
+		}
+		for _, snapshot := range snapshots {
+			c.Ui.Output(fmt.Sprintf("%s\t%d files", snapshot.Name, len(snapshot.Files)))
+		}
+		return 0
+	}
+
+	manifest, err := RestoreSnapshot(c.workingDir, c.snapshot)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error restoring snapshot: %s", err))
+		return 1
+	}
+	for _, file := range manifest.Files {
+		if file.Backup == "" {
+			log.Printf("[INFO] removed %s", file.Path)
+		} else {
+			log.Printf("[INFO] restored %s", file.Path)
+		}
+	}
+	c.Ui.Output(fmt.Sprintf("Snapshot %s is restored.", manifest.Name))
+
+	restored, err := RestoreState(c.Ui, c.workingDir, manifest.Name, c.autoApprove, func(path string) error {
+		terraform, err := tf.NewTerraform(c.workingDir, c.Verbose)
+		if err != nil {
+			return err
+		}
+		if err := terraform.Init(); err != nil {
+			return err
+		}
+		return terraform.StatePush(path)
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error restoring state: %s", err))
+		return 1
+	}
+	if restored {
+		c.Ui.Output(fmt.Sprintf("The state is restored from snapshot %s.", manifest.Name))
+	}
+	return 0
+}
+
+// RestoreState pushes the state backed up in snapshot `name` by `push`, which is only backed up when the migration changes the state by
+// terraform CLI, i.e. `-state-mode=cli`. It asks for confirmation unless `autoApprove` is true, and returns true if the state is restored.
+func RestoreState(ui cli.Ui, workingDirectory string, name string, autoApprove bool, push func(path string) error) (bool, error) {
+	backupFile := filepath.Join(snapshotDirectory(workingDirectory, name), filenameStateBackup)
+	if _, err := os.Stat(backupFile); os.IsNotExist(err) {
+		return false, nil
+	}
+	if !autoApprove {
+		answer, err := ui.Ask(fmt.Sprintf("The state was changed by the migration, do you want to restore it from %s? Only 'yes' will be accepted:", backupFile))
+		if err != nil {
+			return false, err
+		}
+		if strings.TrimSpace(answer) != "yes" {
+			log.Printf("[INFO] the state is not restored, run `terraform state push -force %s` to restore it manually", backupFile)
+			return false, nil
+		}
+	}
+	log.Printf("[INFO] restoring the state from %s...", backupFile)
+	if err := push(backupFile); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *RollbackCommand) Help() string {
+	helpText := `
+Usage: aztfmigrate rollback [-snapshot=<name>] [-list] [-auto-approve]
+` + c.Synopsis() + "\nA snapshot of the Terraform configuration files is taken before each migration. By default, the Terraform state is not\n" +
+		"changed by the migration until the configuration is applied, so restoring the snapshot is enough to undo an unapplied migration.\n" +
+		"With -state-mode=cli, the state is changed by the migration and backed up in the snapshot, it's restored by `terraform state push`\n" +
+		"after confirmation.\n\n" + helpForFlags(c.flags())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RollbackCommand) Synopsis() string {
+	return "Restore the Terraform configuration files and state from the snapshot taken before migration"
+}
@@ -0,0 +1,50 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+	"github.com/mitchellh/cli"
+)
+
+func Test_RestoreState(t *testing.T) {
+	dir := tempDir(t)
+	manifest, err := cmd.CreateSnapshot(dir, []string{filepath.Join(dir, "main.tf")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := ""
+	push := func(path string) error {
+		pushed = path
+		return nil
+	}
+
+	// the state isn't backed up in config mode
+	restored, err := cmd.RestoreState(cli.NewMockUi(), dir, manifest.Name, true, push)
+	if err != nil || restored || pushed != "" {
+		t.Fatalf("expect the state not to be restored, but got %v, %v and %q", restored, err, pushed)
+	}
+
+	backupFile := filepath.Join(dir, ".aztfmigrate", "snapshots", manifest.Name, "terraform.tfstate")
+	if err := os.WriteFile(backupFile, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := cli.NewMockUi()
+	ui.InputReader = strings.NewReader("no\n")
+	restored, err = cmd.RestoreState(ui, dir, manifest.Name, false, push)
+	if err != nil || restored || pushed != "" {
+		t.Fatalf("expect the state not to be restored without confirmation, but got %v, %v and %q", restored, err, pushed)
+	}
+
+	ui = cli.NewMockUi()
+	ui.InputReader = strings.NewReader("yes\n")
+	restored, err = cmd.RestoreState(ui, dir, manifest.Name, false, push)
+	if err != nil || !restored || pushed != backupFile {
+		t.Fatalf("expect the state to be restored from %s, but got %v, %v and %q", backupFile, restored, err, pushed)
+	}
+}
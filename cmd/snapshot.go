@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshotFolderName is the folder in the working directory which contains the snapshots taken before migration
+const snapshotFolderName = ".aztfmigrate/snapshots"
+
+const filenameSnapshotManifest = "manifest.json"
+
+// SnapshotManifest describes the files backed up in a snapshot
+type SnapshotManifest struct {
+	Name      string         `json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	Files     []SnapshotFile `json:"files"`
+	// Addresses are the addresses of the resources to migrate and the migrated resources without the instance keys, they're
+	// used to tell the changes made by the migration from the other changes in the plan
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type SnapshotFile struct {
+	// Path is the path of the original file, relative to the working directory
+	Path string `json:"path"`
+	// Backup is the path of the backup file, relative to the snapshot directory. It's empty if the file didn't exist,
+	// so it will be removed when the snapshot is restored.
+	Backup string `json:"backup,omitempty"`
+}
+
+// CreateSnapshot backs up `files` into a new timestamped folder under the working directory, and writes its manifest which records
+// the resource `addresses` changed by the migration
+func CreateSnapshot(workingDirectory string, files []string, addresses []string) (*SnapshotManifest, error) {
+	createdAt := time.Now()
+	name := createdAt.Format("20060102T150405")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(snapshotDirectory(workingDirectory, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", createdAt.Format("20060102T150405"), i)
+	}
+	dir := snapshotDirectory(workingDirectory, name)
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0750); err != nil {
+		return nil, fmt.Errorf("creating snapshot directory %q: %w", dir, err)
+	}
+
+	manifest := &SnapshotManifest{
+		Name:      name,
+		CreatedAt: createdAt,
+		Files:     make([]SnapshotFile, 0),
+		Addresses: addresses,
+	}
+	visited := make(map[string]bool)
+	for _, file := range files {
+		relativePath, err := filepath.Rel(workingDirectory, file)
+		if err != nil {
+			return nil, err
+		}
+		if visited[relativePath] {
+			continue
+		}
+		visited[relativePath] = true
+
+		snapshotFile := SnapshotFile{
+			Path: filepath.ToSlash(relativePath),
+		}
+		// #nosec G304
+		data, err := os.ReadFile(file)
+		switch {
+		case err == nil:
+			// module files may be outside the working directory, so the backups are named by index instead of path
+			snapshotFile.Backup = fmt.Sprintf("files/%d_%s", len(manifest.Files), filepath.Base(file))
+			if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(snapshotFile.Backup)), data, 0600); err != nil {
+				return nil, fmt.Errorf("backing up %s: %w", file, err)
+			}
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("backing up %s: %w", file, err)
+		}
+		manifest.Files = append(manifest.Files, snapshotFile)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, filenameSnapshotManifest), data, 0600); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ListSnapshots returns the snapshots in the working directory, the latest one is the last
+func ListSnapshots(workingDirectory string) ([]SnapshotManifest, error) {
+	res := make([]SnapshotManifest, 0)
+	entries, err := os.ReadDir(filepath.Join(workingDirectory, filepath.FromSlash(snapshotFolderName)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifest, err := readSnapshotManifest(workingDirectory, entry.Name())
+		if err != nil {
+			// the snapshot might be interrupted before its manifest is written
+			log.Printf("[WARN] skipping snapshot %s: %+v", entry.Name(), err)
+			continue
+		}
+		res = append(res, *manifest)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+// RestoreSnapshot restores the files backed up in snapshot `name`, the latest snapshot is restored if `name` is empty
+func RestoreSnapshot(workingDirectory string, name string) (*SnapshotManifest, error) {
+	if name == "" {
+		snapshots, err := ListSnapshots(workingDirectory)
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("no snapshot is found in %s", workingDirectory)
+		}
+		name = snapshots[len(snapshots)-1].Name
+	}
+	manifest, err := readSnapshotManifest(workingDirectory, name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := snapshotDirectory(workingDirectory, name)
+	for _, file := range manifest.Files {
+		filePath := filepath.Join(workingDirectory, filepath.FromSlash(file.Path))
+		if file.Backup == "" {
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("removing %s: %w", file.Path, err)
+			}
+			continue
+		}
+		// #nosec G304
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Backup)))
+		if err != nil {
+			return nil, fmt.Errorf("reading backup of %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(filePath, data, 0600); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", file.Path, err)
+		}
+	}
+	return manifest, nil
+}
+
+func readSnapshotManifest(workingDirectory string, name string) (*SnapshotManifest, error) {
+	// #nosec G304
+	data, err := os.ReadFile(filepath.Join(snapshotDirectory(workingDirectory, name), filenameSnapshotManifest))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest of snapshot %s: %w", name, err)
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest of snapshot %s: %w", name, err)
+	}
+	return &manifest, nil
+}
+
+func snapshotDirectory(workingDirectory string, name string) string {
+	return filepath.Join(workingDirectory, filepath.FromSlash(snapshotFolderName), name)
+}
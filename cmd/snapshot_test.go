@@ -0,0 +1,69 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+)
+
+func Test_Snapshot(t *testing.T) {
+	dir := tempDir(t)
+	mainFile := filepath.Join(dir, "main.tf")
+	migrationsFile := filepath.Join(dir, "migrations.tf")
+	if err := os.WriteFile(mainFile, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := cmd.CreateSnapshot(dir, []string{mainFile, migrationsFile, mainFile}, []string{"azapi_resource.test", "azurerm_resource_group.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expect 2 files in the snapshot, but got %d", len(manifest.Files))
+	}
+
+	// simulate a migration
+	if err := os.WriteFile(mainFile, []byte("migrated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(migrationsFile, []byte("migrated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := cmd.ListSnapshots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != manifest.Name {
+		t.Fatalf("expect snapshot %s, but got %v", manifest.Name, snapshots)
+	}
+	if len(snapshots[0].Addresses) != 2 || snapshots[0].Addresses[1] != "azurerm_resource_group.test" {
+		t.Fatalf("expect the addresses to be recorded, but got %v", snapshots[0].Addresses)
+	}
+
+	if _, err := cmd.RestoreSnapshot(dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(mainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "original" {
+		t.Fatalf("expect main.tf to be restored, but got %q", string(data))
+	}
+	if _, err := os.Stat(migrationsFile); !os.IsNotExist(err) {
+		t.Fatalf("expect migrations.tf to be removed, but got %v", err)
+	}
+}
+
+func Test_SnapshotNotExist(t *testing.T) {
+	dir := tempDir(t)
+	if _, err := cmd.RestoreSnapshot(dir, ""); err == nil {
+		t.Fatal("expect error when there's no snapshot")
+	}
+	if _, err := cmd.RestoreSnapshot(dir, "not-exist"); err == nil {
+		t.Fatal("expect error when the snapshot doesn't exist")
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// StateModeConfig and StateModeCli are the allowed values of -state-mode. In config mode, the state is updated by the `import`, `removed`
+// and `moved` blocks in the config. In cli mode, it's updated by `terraform state rm` and `terraform import`, which works with terraform < 1.5.
+const (
+	StateModeConfig = "config"
+	StateModeCli    = "cli"
+)
+
+// filenameStateBackup is the state pulled before the state operations in cli mode, it's saved in the snapshot
+const filenameStateBackup = "terraform.tfstate"
+
+// StateOperation is a state change performed by terraform CLI, resource `Address` is removed from the state if `Id` is empty,
+// otherwise resource `Id` is imported to `Address`
+type StateOperation struct {
+	Address string
+	Id      string
+}
+
+func (op StateOperation) String() string {
+	if op.Id == "" {
+		return fmt.Sprintf("terraform state rm %s", op.Address)
+	}
+	return fmt.Sprintf("terraform import %s %s", op.Address, op.Id)
+}
+
+// StateOperations converts the `removed` and `import` blocks to the state operations, the `import` blocks with `for_each` are expanded.
+// The `moved` blocks are not supported, because `terraform state mv` can't move resources across resource types.
+// An error is returned if an `import` block's `id` is not a string or its `for_each` can't be expanded, because a resource would be
+// removed from the state without being imported again.
+func StateOperations(blocks []*hclwrite.Block) ([]StateOperation, error) {
+	res := make([]StateOperation, 0)
+	for _, block := range blocks {
+		switch block.Type() {
+		case "removed":
+			from := attributeSource(block, "from")
+			if from == "" {
+				return nil, fmt.Errorf("removed block doesn't have `from`")
+			}
+			res = append(res, StateOperation{Address: from})
+		case "import":
+			to := attributeSource(block, "to")
+			if to == "" {
+				return nil, fmt.Errorf("import block doesn't have `to`")
+			}
+			forEach := block.Body().GetAttribute("for_each")
+			if forEach == nil {
+				id := ""
+				if attr := block.Body().GetAttribute("id"); attr != nil {
+					id, _ = helper.GetValueFromExpression(attr.Expr().BuildTokens(nil)).(string)
+				}
+				if id == "" {
+					return nil, fmt.Errorf("the id of the import block of %s is not a string: %s", to, attributeSource(block, "id"))
+				}
+				res = append(res, StateOperation{Address: to, Id: id})
+				continue
+			}
+			// the key of for_each is the resource id, and the value is the index of the instance
+			instances, _ := helper.GetValueFromExpression(forEach.Expr().BuildTokens(nil)).(map[string]interface{})
+			if len(instances) == 0 {
+				return nil, fmt.Errorf("the for_each of the import block of %s can't be expanded: %s", to, attributeSource(block, "for_each"))
+			}
+			ids := make([]string, 0)
+			for id := range instances {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				index := fmt.Sprintf("%v", instances[id])
+				if _, ok := instances[id].(string); ok {
+					index = fmt.Sprintf("%q", instances[id])
+				}
+				res = append(res, StateOperation{Address: strings.Replace(to, "[each.value]", "["+index+"]", 1), Id: id})
+			}
+		default:
+			return nil, fmt.Errorf("%s block can't be converted to terraform CLI commands", block.Type())
+		}
+	}
+	return res, nil
+}
+
+// attributeSource returns the expression of attribute `name` in `block` as it's written
+func attributeSource(block *hclwrite.Block, name string) string {
+	attr := block.Body().GetAttribute(name)
+	if attr == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes()))
+}
+
+// applyStateOperations performs `operations` by terraform CLI, the state is backed up in `backupDirectory` before any change and
+// restored if any operation fails
+func applyStateOperations(terraform *tf.Terraform, varFile string, backupDirectory string, operations []StateOperation) error {
+	if len(operations) == 0 {
+		return nil
+	}
+	state, err := terraform.StatePull()
+	if err != nil {
+		return fmt.Errorf("pulling state: %w", err)
+	}
+	backupFile := filepath.Join(backupDirectory, filenameStateBackup)
+	if err := os.MkdirAll(backupDirectory, 0750); err != nil {
+		return fmt.Errorf("creating state backup directory %q: %w", backupDirectory, err)
+	}
+	if err := os.WriteFile(backupFile, []byte(state), 0600); err != nil {
+		return fmt.Errorf("backing up state: %w", err)
+	}
+	log.Printf("[INFO] the state is backed up in %s", backupFile)
+
+	for _, op := range operations {
+		log.Printf("[INFO] running %s...", op)
+		if op.Id == "" {
+			err = terraform.StateRm(op.Address)
+		} else {
+			err = terraform.ImportWithVarFile(op.Address, op.Id, varFile)
+		}
+		if err == nil {
+			continue
+		}
+		log.Printf("[ERROR] %s failed, restoring the state from %s...", op, backupFile)
+		if pushErr := terraform.StatePush(backupFile); pushErr != nil {
+			return fmt.Errorf("%s: %w, and restoring the state from %s failed: %+v", op, err, backupFile, pushErr)
+		}
+		return fmt.Errorf("%s: %w, the state is restored", op, err)
+	}
+	return nil
+}
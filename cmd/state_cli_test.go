@@ -0,0 +1,105 @@
+package cmd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_StateOperations(t *testing.T) {
+	config := `
+removed {
+  from = module.a.azapi_resource.test
+  lifecycle {
+    destroy = false
+  }
+}
+
+import {
+  for_each = {
+    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1" = 1
+    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg0" = 0
+  }
+  id = each.key
+  to = module.a.azurerm_resource_group.test[each.value]
+}
+
+import {
+  id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+  to = azurerm_resource_group.test2
+}
+`
+	f, diags := hclwrite.ParseConfig([]byte(config), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	operations, err := cmd.StateOperations(f.Body().Blocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []cmd.StateOperation{
+		{Address: "module.a.azapi_resource.test"},
+		{Address: "module.a.azurerm_resource_group.test[0]", Id: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg0"},
+		{Address: "module.a.azurerm_resource_group.test[1]", Id: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1"},
+		{Address: "azurerm_resource_group.test2", Id: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"},
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Fatalf("expect %v, but got %v", expected, operations)
+	}
+
+	moved := hclwrite.NewBlock("moved", nil)
+	if _, err := cmd.StateOperations([]*hclwrite.Block{moved}); err == nil {
+		t.Fatal("expect an error for moved block, but got nil")
+	}
+}
+
+func Test_StateOperationsInvalidImport(t *testing.T) {
+	testcases := map[string]string{
+		"id is not a literal": `
+import {
+  id = var.resource_group_id
+  to = azurerm_resource_group.test
+}
+`,
+		"id is missing": `
+import {
+  to = azurerm_resource_group.test
+}
+`,
+		"for_each can't be expanded": `
+import {
+  for_each = local.resource_group_ids
+  id       = each.key
+  to       = azurerm_resource_group.test[each.value]
+}
+`,
+		"for_each is empty": `
+import {
+  for_each = {}
+  id       = each.key
+  to       = azurerm_resource_group.test[each.value]
+}
+`,
+	}
+	for name, config := range testcases {
+		config = `
+removed {
+  from = azapi_resource.test
+  lifecycle {
+    destroy = false
+  }
+}
+` + config
+		f, diags := hclwrite.ParseConfig([]byte(config), "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		// no operation is returned, so the resource is not removed from the state without being imported
+		if operations, err := cmd.StateOperations(f.Body().Blocks()); err == nil {
+			t.Errorf("%s: expect an error, but got operations %v", name, operations)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const filenameTypeMap = "aztfmigrate.typemap"
+
+// TypeMap pre-answers the resource type prompts when there're multiple azurerm resources match the resource id.
+// Each line of the file is in the format `<terraform address or resource id pattern> = <azurerm resource type>`,
+// lines starting with `#` are comments. Resource id patterns support wildcards, e.g. `/subscriptions/*/resourceGroups/*`.
+type TypeMap struct {
+	entries []typeMapEntry
+}
+
+type typeMapEntry struct {
+	key          string
+	resourceType string
+}
+
+// LoadTypeMap reads the type map from `filename`, it returns an empty map if the file doesn't exist
+func LoadTypeMap(filename string) (*TypeMap, error) {
+	m := &TypeMap{
+		entries: make([]typeMapEntry, 0),
+	}
+	// #nosec G304
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	return m, m.parse(string(data))
+}
+
+func (m *TypeMap) parse(content string) error {
+	for index, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %d: expect `<address or resource id pattern> = <resource type>`, got %q", index+1, line)
+		}
+		key := strings.Trim(strings.TrimSpace(parts[0]), `"`)
+		resourceType := strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		if key == "" || resourceType == "" {
+			return fmt.Errorf("line %d: address and resource type can't be empty, got %q", index+1, line)
+		}
+		m.entries = append(m.entries, typeMapEntry{
+			key:          key,
+			resourceType: resourceType,
+		})
+	}
+	return nil
+}
+
+// Lookup returns the resource type for the resource, the terraform address takes precedence over the resource id pattern
+func (m *TypeMap) Lookup(address string, resourceId string) string {
+	if m == nil {
+		return ""
+	}
+	for _, entry := range m.entries {
+		if entry.key == address {
+			return entry.resourceType
+		}
+	}
+	for _, entry := range m.entries {
+		if !strings.HasPrefix(entry.key, "/") {
+			continue
+		}
+		if matched, err := path.Match(strings.ToLower(entry.key), strings.ToLower(resourceId)); err == nil && matched {
+			return entry.resourceType
+		}
+	}
+	return ""
+}
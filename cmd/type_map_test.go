@@ -0,0 +1,73 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+)
+
+func Test_TypeMap(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "aztfmigrate.typemap")
+	err := os.WriteFile(filename, []byte(`
+# web apps
+azapi_resource.app = azurerm_windows_web_app
+"/subscriptions/*/resourceGroups/*/providers/Microsoft.Web/sites/*" = "azurerm_linux_web_app"
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeMap, err := cmd.LoadTypeMap(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		Address    string
+		ResourceId string
+		Expect     string
+	}{
+		{
+			Address:    "azapi_resource.app",
+			ResourceId: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Web/sites/app",
+			Expect:     "azurerm_windows_web_app",
+		},
+		{
+			Address:    "azapi_resource.app2",
+			ResourceId: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/microsoft.web/sites/app2",
+			Expect:     "azurerm_linux_web_app",
+		},
+		{
+			Address:    "azapi_resource.slot",
+			ResourceId: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Web/sites/app/slots/slot",
+			Expect:     "",
+		},
+	}
+
+	for _, testcase := range testcases {
+		if actual := typeMap.Lookup(testcase.Address, testcase.ResourceId); actual != testcase.Expect {
+			t.Errorf("expect %q but got %q, testcase: %#v", testcase.Expect, actual, testcase)
+		}
+	}
+}
+
+func Test_TypeMapInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "aztfmigrate.typemap")
+	if err := os.WriteFile(filename, []byte("azapi_resource.app azurerm_windows_web_app\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cmd.LoadTypeMap(filename); err == nil {
+		t.Fatal("expect an error but got nil")
+	}
+}
+
+func Test_TypeMapNotExist(t *testing.T) {
+	typeMap, err := cmd.LoadTypeMap(filepath.Join(t.TempDir(), "aztfmigrate.typemap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := typeMap.Lookup("azapi_resource.app", "/subscriptions/00000000-0000-0000-0000-000000000000"); actual != "" {
+		t.Fatalf("expect empty resource type but got %q", actual)
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/types"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	VerifyStatusImport     = "import"
+	VerifyStatusRemoved    = "removed"
+	VerifyStatusMoved      = "moved"
+	VerifyStatusUnexpected = "unexpected"
+	// VerifyStatusDrift is a change of the resource which is not changed by the migration, it doesn't fail the verification
+	VerifyStatusDrift = "drift"
+)
+
+// VerifyResult describes the changes in the plan of the migrated configuration
+type VerifyResult struct {
+	Changes []VerifyChange
+}
+
+type VerifyChange struct {
+	Address string
+	Status  string
+	Actions tfjson.Actions
+	// Diffs are the attributes changed by the plan, they're only collected for the unexpected changes of the migrated resources
+	Diffs []AttributeDiff
+}
+
+type AttributeDiff struct {
+	Path    string
+	Before  interface{}
+	After   interface{}
+	Unknown bool
+}
+
+// VerifyPlan classifies the resource changes in plan `p`. After migration, the plan is expected to only import the migrated resources,
+// remove the original resources from the state or move them, any other change means the migrated configuration doesn't match the infrastructure.
+// `addresses` are the addresses of the resources changed by the migration without the instance keys, the changes of other resources are
+// reported as drift. All resources are checked if it's nil.
+func VerifyPlan(p *tfjson.Plan, addresses map[string]bool) *VerifyResult {
+	res := &VerifyResult{
+		Changes: make([]VerifyChange, 0),
+	}
+	if p == nil {
+		return res
+	}
+	for _, resourceChange := range p.ResourceChanges {
+		if resourceChange == nil || resourceChange.Change == nil {
+			continue
+		}
+		actions := resourceChange.Change.Actions
+		change := VerifyChange{
+			Address: resourceChange.Address,
+			Actions: actions,
+		}
+		switch {
+		case actions.Forget():
+			change.Status = VerifyStatusRemoved
+		case resourceChange.Change.Importing != nil && actions.NoOp():
+			change.Status = VerifyStatusImport
+		case resourceChange.PreviousAddress != "" && resourceChange.PreviousAddress != resourceChange.Address && actions.NoOp():
+			change.Status = VerifyStatusMoved
+		case actions.NoOp() || actions.Read():
+			continue
+		case addresses != nil && !addresses[types.ResourceAddress(resourceChange.Address)]:
+			change.Status = VerifyStatusDrift
+		default:
+			change.Status = VerifyStatusUnexpected
+			change.Diffs = diffAttributes(resourceChange.Change)
+		}
+		res.Changes = append(res.Changes, change)
+	}
+	sort.SliceStable(res.Changes, func(i, j int) bool {
+		return res.Changes[i].Address < res.Changes[j].Address
+	})
+	return res
+}
+
+// HasDrift returns true if there's any unexpected change of the migrated resources
+func (r *VerifyResult) HasDrift() bool {
+	for _, change := range r.Changes {
+		if change.Status == VerifyStatusUnexpected {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *VerifyResult) String() string {
+	expectedMessage := ""
+	unexpectedMessage := ""
+	driftMessage := ""
+	for _, change := range r.Changes {
+		actions := make([]string, 0)
+		for _, action := range change.Actions {
+			actions = append(actions, string(action))
+		}
+		switch change.Status {
+		case VerifyStatusUnexpected:
+		case VerifyStatusDrift:
+			driftMessage += fmt.Sprintf("\t%s: %s\n", change.Address, strings.Join(actions, ", "))
+			continue
+		default:
+			expectedMessage += fmt.Sprintf("\t%s: %s\n", change.Address, change.Status)
+			continue
+		}
+		unexpectedMessage += fmt.Sprintf("\t%s: %s\n", change.Address, strings.Join(actions, ", "))
+		for _, diff := range change.Diffs {
+			after := formatValue(diff.After)
+			if diff.Unknown {
+				after = "(known after apply)"
+			}
+			unexpectedMessage += fmt.Sprintf("\t\t~ %s: %s => %s\n", diff.Path, formatValue(diff.Before), after)
+		}
+	}
+	res := ""
+	if expectedMessage != "" {
+		res += "The following changes are expected:\n" + expectedMessage + "\n"
+	}
+	if unexpectedMessage != "" {
+		res += "The following changes are unexpected:\n" + unexpectedMessage + "\n"
+	}
+	if driftMessage != "" {
+		res += "The following changes are not made by the migration, they're not verified:\n" + driftMessage + "\n"
+	}
+	if res == "" {
+		res = "No changes. The migrated configuration matches the infrastructure.\n"
+	}
+	return res
+}
+
+// diffAttributes returns the attributes whose values are changed, nested attributes are flattened, e.g. `tags.env`
+func diffAttributes(change *tfjson.Change) []AttributeDiff {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	unknown := make(map[string]interface{})
+	flattenValues(change.Before, "", before)
+	flattenValues(change.After, "", after)
+	flattenValues(change.AfterUnknown, "", unknown)
+
+	paths := make(map[string]bool)
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+	for path, value := range unknown {
+		if value == true {
+			paths[path] = true
+		}
+	}
+
+	res := make([]AttributeDiff, 0)
+	for path := range paths {
+		isUnknown := unknown[path] == true
+		if !isUnknown && reflect.DeepEqual(before[path], after[path]) {
+			continue
+		}
+		res = append(res, AttributeDiff{
+			Path:    path,
+			Before:  before[path],
+			After:   after[path],
+			Unknown: isUnknown,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
+
+func flattenValues(input interface{}, prefix string, res map[string]interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := input.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			flattenValues(value, join(key), res)
+		}
+	case []interface{}:
+		for index, value := range v {
+			flattenValues(value, join(fmt.Sprintf("%d", index)), res)
+		}
+	default:
+		if prefix != "" && v != nil {
+			res[prefix] = v
+		}
+	}
+}
+
+func formatValue(input interface{}) string {
+	switch v := input.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
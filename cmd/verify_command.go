@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/mitchellh/cli"
+)
+
+type VerifyCommand struct {
+	Ui         cli.Ui
+	Verbose    bool
+	workingDir string
+	varFile    string
+}
+
+func (c *VerifyCommand) flags() *flag.FlagSet {
+	fs := defaultFlagSet("verify")
+	fs.BoolVar(&c.Verbose, "v", false, "whether show terraform logs")
+	fs.StringVar(&c.workingDir, "working-dir", "", "path to Terraform configuration files")
+	fs.StringVar(&c.varFile, "var-file", "", "path to the terraform variable file")
+	fs.Usage = func() { c.Ui.Error(c.Help()) }
+	return fs
+}
+
+func (c *VerifyCommand) Run(args []string) int {
+	f := c.flags()
+	if err := f.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s", err))
+		return 1
+	}
+	if c.workingDir == "" {
+		c.workingDir, _ = os.Getwd()
+	}
+
+	log.Printf("[INFO] initializing terraform...")
+	terraform, err := tf.NewTerraform(c.workingDir, c.Verbose)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return Verify(c.Ui, terraform, c.varFile)
+}
+
+func (c *VerifyCommand) Help() string {
+	helpText := `
+Usage: aztfmigrate verify
+` + c.Synopsis() + "\nThe plan of the migrated configuration is expected to only import, remove or move resources, the command exits with a\n" +
+		"non-zero code when there're other changes of the resources recorded by the latest migration.\n\n" + helpForFlags(c.flags())
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VerifyCommand) Synopsis() string {
+	return "Verify that the migrated configuration matches the infrastructure"
+}
+
+// Verify runs terraform plan on the migrated configuration and prints the changes, it returns the exit code which is non-zero on drift
+func Verify(ui cli.Ui, terraform *tf.Terraform, varFile string) int {
+	log.Printf("[INFO] running terraform plan to verify the migrated configuration...")
+	p, err := terraform.Plan(&varFile)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error running terraform plan: %s", err))
+		return 1
+	}
+	result := VerifyPlan(p, migratedAddresses(terraform.GetWorkingDirectory()))
+	ui.Output(result.String())
+	if result.HasDrift() {
+		ui.Error("The migrated configuration doesn't match the infrastructure, run `aztfmigrate rollback` to restore the configuration.")
+		return 1
+	}
+	return 0
+}
+
+// migratedAddresses returns the resource addresses recorded by the latest migration, it returns nil if there's no migration
+func migratedAddresses(workingDirectory string) map[string]bool {
+	snapshots, err := ListSnapshots(workingDirectory)
+	if err != nil {
+		log.Printf("[WARN] listing snapshots: %+v", err)
+		return nil
+	}
+	if len(snapshots) == 0 || len(snapshots[len(snapshots)-1].Addresses) == 0 {
+		log.Printf("[WARN] no migration is recorded in the snapshots, the changes of all resources are verified")
+		return nil
+	}
+	res := make(map[string]bool)
+	for _, address := range snapshots[len(snapshots)-1].Addresses {
+		res[address] = true
+	}
+	return res
+}
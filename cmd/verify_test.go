@@ -0,0 +1,104 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/cmd"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_VerifyPlan(t *testing.T) {
+	planJson := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "azurerm_resource_group.imported",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "imported",
+      "change": {
+        "actions": ["no-op"],
+        "before": {"name": "rg"},
+        "after": {"name": "rg"},
+        "importing": {"id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"}
+      }
+    },
+    {
+      "address": "azapi_resource.removed",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "removed",
+      "change": {
+        "actions": ["forget"],
+        "before": {"name": "rg"}
+      }
+    },
+    {
+      "address": "azurerm_resource_group.unchanged",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "unchanged",
+      "change": {
+        "actions": ["no-op"],
+        "before": {"name": "rg2"},
+        "after": {"name": "rg2"}
+      }
+    },
+    {
+      "address": "azurerm_resource_group.drifted",
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "drifted",
+      "change": {
+        "actions": ["update"],
+        "before": {"name": "rg3", "tags": {"env": "dev"}},
+        "after": {"name": "rg3", "tags": {"env": "prod"}},
+        "after_unknown": {},
+        "importing": {"id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg3"}
+      }
+    }
+  ]
+}`
+	var p tfjson.Plan
+	if err := json.Unmarshal([]byte(planJson), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	result := cmd.VerifyPlan(&p, nil)
+	if !result.HasDrift() {
+		t.Fatal("expect drift, but got none")
+	}
+	expected := map[string]string{
+		"azurerm_resource_group.imported": cmd.VerifyStatusImport,
+		"azapi_resource.removed":          cmd.VerifyStatusRemoved,
+		"azurerm_resource_group.drifted":  cmd.VerifyStatusUnexpected,
+	}
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("expect %d changes, but got %d", len(expected), len(result.Changes))
+	}
+	for _, change := range result.Changes {
+		if expected[change.Address] != change.Status {
+			t.Fatalf("expect %s to be %s, but got %s", change.Address, expected[change.Address], change.Status)
+		}
+		if change.Status == cmd.VerifyStatusUnexpected {
+			if len(change.Diffs) != 1 || change.Diffs[0].Path != "tags.env" || change.Diffs[0].Before != "dev" || change.Diffs[0].After != "prod" {
+				t.Fatalf("expect tags.env to be changed from dev to prod, but got %+v", change.Diffs)
+			}
+		}
+	}
+
+	// the changes of the resources which are not changed by the migration are reported as drift, which doesn't fail the verification
+	result = cmd.VerifyPlan(&p, map[string]bool{
+		"azurerm_resource_group.imported": true,
+		"azapi_resource.removed":          true,
+	})
+	if result.HasDrift() {
+		t.Fatalf("expect no unexpected change, but got %s", result)
+	}
+	for _, change := range result.Changes {
+		if change.Address == "azurerm_resource_group.drifted" && (change.Status != cmd.VerifyStatusDrift || len(change.Diffs) != 0) {
+			t.Fatalf("expect azurerm_resource_group.drifted to be drift without diffs, but got %+v", change)
+		}
+	}
+}
@@ -14,6 +14,7 @@ require (
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.40.1
 	github.com/magodo/aztft v0.3.1-0.20260715003534-2c6101a24b3e
 	github.com/magodo/tfadd v0.10.1-0.20240902124619-bd18a56f410d
+	github.com/magodo/tfpluginschema v0.0.0-20240902090353-0525d7d8c1c2
 	github.com/mitchellh/cli v1.1.5
 	github.com/zclconf/go-cty v1.19.0
 )
@@ -92,7 +93,6 @@ require (
 	github.com/hashicorp/terraform-plugin-log v0.10.0 // indirect
 	github.com/huandu/xstrings v1.5.0 // indirect
 	github.com/magodo/armid v0.0.0-20250724105512-5cedfa9dd8e2 // indirect
-	github.com/magodo/tfpluginschema v0.0.0-20240902090353-0525d7d8c1c2 // indirect
 	github.com/magodo/tfstate v0.0.0-20241016043929-2c95177bf0e6 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
@@ -0,0 +1,104 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileSystem reads and writes the terraform configuration files
+type FileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+	// ReadDir returns the names of the files in directory `name`, sorted by name
+	ReadDir(name string) ([]string, error)
+}
+
+var _ FileSystem = OsFileSystem{}
+var _ FileSystem = &MemoryFileSystem{}
+
+// OsFileSystem reads and writes the files on disk
+type OsFileSystem struct{}
+
+func (OsFileSystem) ReadFile(name string) ([]byte, error) {
+	// #nosec G304
+	return os.ReadFile(name)
+}
+
+// WriteFile writes `data` to file `name`, its parent directory is created if it doesn't exist
+func (OsFileSystem) WriteFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0600)
+}
+
+func (OsFileSystem) ReadDir(name string) ([]string, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			res = append(res, entry.Name())
+		}
+	}
+	return res, nil
+}
+
+// MemoryFileSystem reads the files on disk, but keeps the written files in memory, so the files on disk are left unchanged
+type MemoryFileSystem struct {
+	files map[string][]byte
+}
+
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return &MemoryFileSystem{
+		files: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryFileSystem) ReadFile(name string) ([]byte, error) {
+	if data, ok := m.files[name]; ok {
+		return append([]byte{}, data...), nil
+	}
+	// #nosec G304
+	return os.ReadFile(name)
+}
+
+func (m *MemoryFileSystem) WriteFile(name string, data []byte) error {
+	m.files[name] = append([]byte{}, data...)
+	return nil
+}
+
+// ReadDir returns the names of the files in directory `name` on disk and the files written in memory in it
+func (m *MemoryFileSystem) ReadDir(name string) ([]string, error) {
+	res, err := OsFileSystem{}.ReadDir(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	exists := make(map[string]bool)
+	for _, file := range res {
+		exists[file] = true
+	}
+	for file := range m.files {
+		if filepath.Dir(file) == filepath.Clean(name) && !exists[filepath.Base(file)] {
+			res = append(res, filepath.Base(file))
+		}
+	}
+	if err != nil && len(res) == 0 {
+		return nil, err
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// WrittenFiles returns the names of the files written in memory, sorted by name
+func (m *MemoryFileSystem) WrittenFiles() []string {
+	res := make([]string, 0)
+	for name := range m.files {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
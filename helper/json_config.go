@@ -0,0 +1,620 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyJson "github.com/zclconf/go-cty/cty/json"
+)
+
+// JsonBlockSchema is the schema of a block in JSON syntax, the nested blocks can't be told apart from the object attributes without it
+type JsonBlockSchema struct {
+	Labels int
+	Blocks map[string]*JsonBlockSchema
+}
+
+// JsonSchemaFunc returns the schema of top level block `blockType` with `labels`, it returns nil if the block has no nested blocks
+type JsonSchemaFunc func(blockType string, labels []string) *JsonBlockSchema
+
+// topLevelLabels are the numbers of labels of the top level blocks
+var topLevelLabels = map[string]int{
+	"resource": 2,
+	"data":     2,
+	"module":   1,
+	"provider": 1,
+	"variable": 1,
+	"output":   1,
+	"check":    1,
+}
+
+// terraformBlockSchema is the schema of `terraform` block
+var terraformBlockSchema = &JsonBlockSchema{
+	Blocks: map[string]*JsonBlockSchema{
+		"required_providers": {},
+		"backend":            {Labels: 1},
+		"cloud":              {Blocks: map[string]*JsonBlockSchema{"workspaces": {}}},
+	},
+}
+
+// bareKeys are the arguments whose strings are expressions instead of templates in JSON syntax, e.g. `"depends_on": ["azurerm_resource_group.test"]`
+var bareKeys = map[string]bool{
+	"depends_on":           true,
+	"ignore_changes":       true,
+	"replace_triggered_by": true,
+	"provider":             true,
+	"from":                 true,
+	"to":                   true,
+}
+
+// JsonObject is a JSON object whose members are kept in order, the member values are json.RawMessage or JsonObject
+type JsonObject []JsonMember
+
+type JsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+// ParseJsonObject parses JSON object `src`, the member values are kept as json.RawMessage
+func ParseJsonObject(src []byte) (JsonObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expect JSON object")
+	}
+	res := make(JsonObject, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect object key, but got %v", token)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		res = append(res, JsonMember{Key: key, Value: value})
+	}
+	return res, nil
+}
+
+func (o JsonObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString("{")
+	for i, member := range o {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		key, err := marshalJson(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJson(member.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// Get returns the value of member `key`
+func (o JsonObject) Get(key string) (interface{}, bool) {
+	for _, member := range o {
+		if member.Key == key {
+			return member.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of member `key`, the member is appended if it doesn't exist
+func (o JsonObject) Set(key string, value interface{}) JsonObject {
+	for i, member := range o {
+		if member.Key == key {
+			o[i].Value = value
+			return o
+		}
+	}
+	return append(o, JsonMember{Key: key, Value: value})
+}
+
+// Remove removes member `key`
+func (o JsonObject) Remove(key string) JsonObject {
+	res := make(JsonObject, 0)
+	for _, member := range o {
+		if member.Key != key {
+			res = append(res, member)
+		}
+	}
+	return res
+}
+
+// FormatJson returns the indented JSON of `input`, the HTML characters are not escaped
+func FormatJson(input interface{}) ([]byte, error) {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(input); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalJson(input interface{}) ([]byte, error) {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(input); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// JsonBlocks returns the top level blocks in JSON config `src` in native syntax, `schemaFunc` tells the nested blocks of the blocks
+func JsonBlocks(src []byte, schemaFunc JsonSchemaFunc) ([]*hclwrite.Block, error) {
+	root, err := ParseJsonObject(src)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*hclwrite.Block, 0)
+	for _, member := range root {
+		if member.Key == "//" {
+			continue
+		}
+		raw, _ := member.Value.(json.RawMessage)
+		bodies, err := labeledBodies(raw, topLevelLabels[member.Key], nil)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %+v", member.Key, err)
+		}
+		for _, body := range bodies {
+			var sch *JsonBlockSchema
+			switch {
+			case member.Key == "terraform":
+				sch = terraformBlockSchema
+			case schemaFunc != nil:
+				sch = schemaFunc(member.Key, body.labels)
+			}
+			block, err := jsonBlock(member.Key, body.labels, body.raw, sch)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, block)
+		}
+	}
+	return res, nil
+}
+
+type jsonBody struct {
+	labels []string
+	raw    json.RawMessage
+}
+
+// labeledBodies returns the bodies of the blocks in `raw`, which is nested in objects keyed by `labels` labels.
+// Each level can be an array of objects too.
+func labeledBodies(raw json.RawMessage, labels int, parentLabels []string) ([]jsonBody, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elements); err != nil {
+			return nil, err
+		}
+		res := make([]jsonBody, 0)
+		for _, element := range elements {
+			bodies, err := labeledBodies(element, labels, parentLabels)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, bodies...)
+		}
+		return res, nil
+	}
+	if labels == 0 {
+		return []jsonBody{{labels: parentLabels, raw: raw}}, nil
+	}
+	obj, err := ParseJsonObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]jsonBody, 0)
+	for _, member := range obj {
+		if member.Key == "//" {
+			continue
+		}
+		bodies, err := labeledBodies(member.Value.(json.RawMessage), labels-1, append(append([]string{}, parentLabels...), member.Key))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, bodies...)
+	}
+	return res, nil
+}
+
+// jsonBlock converts block body `raw` in JSON syntax to native syntax
+func jsonBlock(blockType string, labels []string, raw json.RawMessage, sch *JsonBlockSchema) (*hclwrite.Block, error) {
+	obj, err := ParseJsonObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	block := hclwrite.NewBlock(blockType, labels)
+	for _, member := range obj {
+		if member.Key == "//" {
+			continue
+		}
+		value := member.Value.(json.RawMessage)
+		if sch != nil && sch.Blocks[member.Key] != nil {
+			nestedSchema := sch.Blocks[member.Key]
+			bodies, err := labeledBodies(value, nestedSchema.Labels, nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, body := range bodies {
+				nestedBlock, err := jsonBlock(member.Key, body.labels, body.raw, nestedSchema)
+				if err != nil {
+					return nil, err
+				}
+				block.Body().AppendBlock(nestedBlock)
+			}
+			continue
+		}
+		expr, err := jsonExpression(value, bareKeys[member.Key])
+		if err != nil {
+			return nil, err
+		}
+		block.Body().SetAttributeRaw(member.Key, GetTokensForExpression(expr))
+	}
+	return block, nil
+}
+
+// jsonExpression returns the expression in native syntax of JSON value `raw`, the strings are expressions if `bare` is true, otherwise templates
+func jsonExpression(raw json.RawMessage, bare bool) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case string:
+		if bare {
+			return v, nil
+		}
+		return templateExpression(v)
+	case map[string]interface{}:
+		obj, err := ParseJsonObject(raw)
+		if err != nil {
+			return "", err
+		}
+		if len(obj) == 0 {
+			return "{}", nil
+		}
+		items := make([]string, 0)
+		for _, member := range obj {
+			expr, err := jsonExpression(member.Value.(json.RawMessage), bare)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, fmt.Sprintf("%s = %s", quotedKey(member.Key), expr))
+		}
+		return fmt.Sprintf("{\n%s\n}", strings.Join(items, "\n")), nil
+	case []interface{}:
+		var elements []json.RawMessage
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return "", err
+		}
+		items := make([]string, 0)
+		for _, element := range elements {
+			expr, err := jsonExpression(element, bare)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, expr)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", ")), nil
+	default:
+		return strings.TrimSpace(string(raw)), nil
+	}
+}
+
+// templateExpression returns the quoted template in native syntax of string `input` in JSON syntax
+func templateExpression(input string) (string, error) {
+	if !strings.Contains(input, "${") && !strings.Contains(input, "%{") {
+		return string(hclwrite.TokensForValue(cty.StringVal(input)).Bytes()), nil
+	}
+	expr, diags := hclsyntax.ParseTemplate([]byte(input), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("parsing template %q: %s", input, diags.Error())
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		// "${var.name}" is var.name
+		return string(e.Wrapped.Range().SliceBytes([]byte(input))), nil
+	case *hclsyntax.TemplateExpr:
+		if strings.Contains(input, "%{") {
+			break
+		}
+		// the literal parts are escaped, and the interpolations are kept as they're written
+		res := strings.Builder{}
+		res.WriteString(`"`)
+		for _, part := range e.Parts {
+			if literal, ok := part.(*hclsyntax.LiteralValueExpr); ok && literal.Val.Type() == cty.String {
+				quoted := string(hclwrite.TokensForValue(literal.Val).Bytes())
+				res.WriteString(quoted[1 : len(quoted)-1])
+				continue
+			}
+			res.WriteString("${")
+			res.Write(part.Range().SliceBytes([]byte(input)))
+			res.WriteString("}")
+		}
+		res.WriteString(`"`)
+		return res.String(), nil
+	}
+	return `"` + strings.NewReplacer("\n", `\n`, `"`, `\"`).Replace(input) + `"`, nil
+}
+
+// BlockToJson returns the body of `block` in JSON syntax, the nested blocks of the same type are converted to an array
+func BlockToJson(block *hclwrite.Block) (JsonObject, error) {
+	file := hclwrite.NewEmptyFile()
+	file.Body().AppendBlock(block)
+	src := hclwrite.Format(file.Bytes())
+	f, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing block: %s", diags.Error())
+	}
+	body := f.Body.(*hclsyntax.Body)
+	if len(body.Blocks) != 1 {
+		return nil, fmt.Errorf("expect one block, but got %d", len(body.Blocks))
+	}
+	return syntaxBodyToJson(body.Blocks[0].Body, src)
+}
+
+func syntaxBodyToJson(body *hclsyntax.Body, src []byte) (JsonObject, error) {
+	res := make(JsonObject, 0)
+	attrs := make([]*hclsyntax.Attribute, 0)
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	for _, attr := range attrs {
+		value, err := syntaxExpressionToJson(attr.Expr, src, bareKeys[attr.Name])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, JsonMember{Key: attr.Name, Value: value})
+	}
+
+	blocksMap := make(map[string][]interface{})
+	blockTypes := make([]string, 0)
+	for _, block := range body.Blocks {
+		nested, err := syntaxBodyToJson(block.Body, src)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = nestJsonLabels(block.Labels, nested)
+		if _, ok := blocksMap[block.Type]; !ok {
+			blockTypes = append(blockTypes, block.Type)
+		}
+		blocksMap[block.Type] = append(blocksMap[block.Type], value)
+	}
+	for _, blockType := range blockTypes {
+		if len(blocksMap[blockType]) == 1 {
+			res = append(res, JsonMember{Key: blockType, Value: blocksMap[blockType][0]})
+		} else {
+			res = append(res, JsonMember{Key: blockType, Value: blocksMap[blockType]})
+		}
+	}
+	return res, nil
+}
+
+// syntaxExpressionToJson returns the JSON value of `expr`, the literal values are kept, and the other expressions are converted to templates
+func syntaxExpressionToJson(expr hclsyntax.Expression, src []byte, bare bool) (interface{}, error) {
+	source := string(expr.Range().SliceBytes(src))
+	if bare {
+		if tuple, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+			res := make([]interface{}, 0)
+			for _, element := range tuple.Exprs {
+				res = append(res, string(element.Range().SliceBytes(src)))
+			}
+			return res, nil
+		}
+		return source, nil
+	}
+	if value, diags := expr.Value(nil); !diags.HasErrors() && value.IsWhollyKnown() {
+		data, err := ctyJson.Marshal(value, value.Type())
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(data), nil
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + string(e.Wrapped.Range().SliceBytes(src)) + "}", nil
+	case *hclsyntax.TemplateExpr:
+		if strings.HasPrefix(source, `"`) {
+			// the escapes in the quoted template are compatible with JSON, except the quotes in the interpolations
+			var res string
+			if err := json.Unmarshal([]byte(source), &res); err == nil {
+				return res, nil
+			}
+			return strings.Trim(source, `"`), nil
+		}
+	case *hclsyntax.ObjectConsExpr:
+		res := make(JsonObject, 0)
+		for _, item := range e.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !key.Type().Equals(cty.String) {
+				return "${" + source + "}", nil
+			}
+			value, err := syntaxExpressionToJson(item.ValueExpr, src, false)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, JsonMember{Key: key.AsString(), Value: value})
+		}
+		return res, nil
+	case *hclsyntax.TupleConsExpr:
+		res := make([]interface{}, 0)
+		for _, element := range e.Exprs {
+			value, err := syntaxExpressionToJson(element, src, false)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, value)
+		}
+		return res, nil
+	}
+	return "${" + source + "}", nil
+}
+
+// UpdateJsonBlock updates the body of top level block `blockType` with `labels` in JSON config `root` to the result of `update`,
+// the block is removed if the result is nil. It returns false if the block is not found.
+func UpdateJsonBlock(root JsonObject, blockType string, labels []string, update func(body json.RawMessage) (interface{}, error)) (JsonObject, bool, error) {
+	value, found, err := updateJsonValue(root, append([]string{blockType}, labels...), update)
+	if err != nil || !found {
+		return root, found, err
+	}
+	if value == nil {
+		return make(JsonObject, 0), true, nil
+	}
+	return value.(JsonObject), true, nil
+}
+
+// updateJsonValue updates the value at `path` in `input`, which is an object or an array of objects. It returns nil if `input` becomes empty.
+func updateJsonValue(input interface{}, path []string, update func(body json.RawMessage) (interface{}, error)) (interface{}, bool, error) {
+	raw, err := marshalJson(input)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(path) == 0 {
+		value, err := update(raw)
+		return value, true, err
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) != 0 && trimmed[0] == '[' {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elements); err != nil {
+			return nil, false, err
+		}
+		res := make([]interface{}, 0)
+		found := false
+		for _, element := range elements {
+			if found {
+				res = append(res, element)
+				continue
+			}
+			value, ok, err := updateJsonValue(element, path, update)
+			if err != nil {
+				return nil, false, err
+			}
+			found = ok
+			if !ok {
+				res = append(res, element)
+			} else if value != nil {
+				res = append(res, value)
+			}
+		}
+		if len(res) == 0 {
+			return nil, found, nil
+		}
+		return res, found, nil
+	}
+
+	obj, ok := input.(JsonObject)
+	if !ok {
+		if obj, err = ParseJsonObject(raw); err != nil {
+			return nil, false, err
+		}
+	}
+	member, ok := obj.Get(path[0])
+	if !ok || path[0] == "//" {
+		return obj, false, nil
+	}
+	value, found, err := updateJsonValue(member, path[1:], update)
+	if err != nil || !found {
+		return obj, found, err
+	}
+	if value == nil {
+		obj = obj.Remove(path[0])
+	} else {
+		obj = obj.Set(path[0], value)
+	}
+	if len(obj) == 0 {
+		return nil, true, nil
+	}
+	return obj, true, nil
+}
+
+// AppendJsonBlock appends top level `block` to JSON config `root`, the block is merged into the existing object of the same block type and labels.
+// The `comment` is added to the block body as the `//` property if it's not empty.
+func AppendJsonBlock(root JsonObject, block *hclwrite.Block, comment string) (JsonObject, error) {
+	body, err := BlockToJson(block)
+	if err != nil {
+		return nil, err
+	}
+	if comment != "" {
+		body = append(JsonObject{{Key: "//", Value: comment}}, body...)
+	}
+	existing, ok := root.Get(block.Type())
+	if !ok {
+		if len(block.Labels()) == 0 && block.Type() != "terraform" && block.Type() != "locals" {
+			// moved, import and removed blocks can be repeated
+			return root.Set(block.Type(), []interface{}{body}), nil
+		}
+		return root.Set(block.Type(), nestJsonLabels(block.Labels(), body)), nil
+	}
+	value, err := appendJsonValue(existing, block.Labels(), body)
+	if err != nil {
+		return nil, err
+	}
+	return root.Set(block.Type(), value), nil
+}
+
+// appendJsonValue appends `body` nested by `labels` to `input`, which is an object or an array of objects
+func appendJsonValue(input interface{}, labels []string, body JsonObject) (interface{}, error) {
+	raw, err := marshalJson(input)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) != 0 && trimmed[0] == '[' {
+		var elements []interface{}
+		if err := json.Unmarshal(trimmed, &elements); err != nil {
+			return nil, err
+		}
+		return append(elements, nestJsonLabels(labels, body)), nil
+	}
+	if len(labels) == 0 {
+		return []interface{}{json.RawMessage(raw), body}, nil
+	}
+	obj, err := ParseJsonObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	existing, ok := obj.Get(labels[0])
+	if !ok || len(labels) == 1 {
+		return obj.Set(labels[0], nestJsonLabels(labels[1:], body)), nil
+	}
+	value, err := appendJsonValue(existing, labels[1:], body)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Set(labels[0], value), nil
+}
+
+func nestJsonLabels(labels []string, body JsonObject) JsonObject {
+	res := body
+	for i := len(labels) - 1; i >= 0; i-- {
+		res = JsonObject{{Key: labels[i], Value: res}}
+	}
+	return res
+}
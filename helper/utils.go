@@ -80,17 +80,44 @@ func ListHclFiles(workingDirectory string) []fs.DirEntry {
 	return res
 }
 
+// ListConfigFiles returns the terraform configuration files in both native syntax and JSON syntax, e.g. `main.tf` and `main.tf.json`
+func ListConfigFiles(fs FileSystem, workingDirectory string) []string {
+	res := make([]string, 0)
+	files, err := fs.ReadDir(workingDirectory)
+	if err != nil {
+		return res
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".tf") || IsJsonConfig(file) {
+			res = append(res, file)
+		}
+	}
+	return res
+}
+
+// IsJsonConfig returns true if `filename` is a terraform configuration file in JSON syntax
+func IsJsonConfig(filename string) bool {
+	return strings.HasSuffix(filename, ".tf.json")
+}
+
+// ListHclBlocks returns the top level blocks in the config files in working directory, the blocks in JSON syntax are converted to native syntax
 func ListHclBlocks(workingDirectory string) []*hclwrite.Block {
 	res := make([]*hclwrite.Block, 0)
-	files := ListHclFiles(workingDirectory)
+	files := ListConfigFiles(OsFileSystem{}, workingDirectory)
 	for _, file := range files {
-		filePath := path.Join(workingDirectory, file.Name())
+		filePath := path.Join(workingDirectory, file)
 		// #nosec G304
 		f, err := os.ReadFile(filePath)
 		if err != nil {
 			continue
 		}
-		hclFile, diags := hclwrite.ParseConfig(f, file.Name(), hcl.InitialPos)
+		if IsJsonConfig(file) {
+			if blocks, err := JsonBlocks(f, nil); err == nil {
+				res = append(res, blocks...)
+			}
+			continue
+		}
+		hclFile, diags := hclwrite.ParseConfig(f, file, hcl.InitialPos)
 		if diags.HasErrors() {
 			continue
 		}
@@ -152,6 +179,24 @@ func ParseHclArray(attrValue string) []string {
 
 // ToHclSearchReplace generates hcl expression from `input`
 func ToHclSearchReplace(input interface{}, search []string, replacement []string) (string, bool) {
+	return ToHclReplaceFunc(input, "", func(_ string, value interface{}) (string, bool) {
+		if stringValue, ok := value.(string); ok {
+			for i := range search {
+				if search[i] == stringValue {
+					return replacement[i], true
+				}
+			}
+		}
+		return "", false
+	})
+}
+
+// ToHclReplaceFunc generates hcl expression from `input`, the values which `replace` returns true for are replaced with its result.
+// `path` is the path of `input` separated by `.`, the object keys are appended to it, and the elements of a list have the path of the list.
+func ToHclReplaceFunc(input interface{}, path string, replace func(path string, value interface{}) (string, bool)) (string, bool) {
+	if output, ok := replace(path, input); ok {
+		return output, true
+	}
 	found := false
 	switch value := input.(type) {
 	case []interface{}:
@@ -160,7 +205,7 @@ func ToHclSearchReplace(input interface{}, search []string, replacement []string
 		}
 		res := make([]string, 0)
 		for _, element := range value {
-			config, ok := ToHclSearchReplace(element, search, replacement)
+			config, ok := ToHclReplaceFunc(element, path, replace)
 			found = found || ok
 			res = append(res, config)
 		}
@@ -175,17 +220,16 @@ func ToHclSearchReplace(input interface{}, search []string, replacement []string
 				attrs = append(attrs, fmt.Sprintf("%s = null", quotedKey(k)))
 				continue
 			}
-			config, ok := ToHclSearchReplace(v, search, replacement)
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			config, ok := ToHclReplaceFunc(v, childPath, replace)
 			found = found || ok
 			attrs = append(attrs, fmt.Sprintf("%s = %s", quotedKey(k), config))
 		}
 		return fmt.Sprintf("{\n%s\n}", strings.Join(attrs, "\n")), found
 	case string:
-		for i := range search {
-			if search[i] == value {
-				return replacement[i], true
-			}
-		}
 		return fmt.Sprintf(`"%s"`, strings.ReplaceAll(value, "\"", "\\\"")), false
 	default:
 		return fmt.Sprintf("%v", value), false
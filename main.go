@@ -27,6 +27,11 @@ func main() {
 	}
 
 	c.Commands = map[string]cli.CommandFactory{
+		"finalize": func() (cli.Command, error) {
+			return &cmd.FinalizeCommand{
+				Ui: ui,
+			}, nil
+		},
 		"migrate": func() (cli.Command, error) {
 			return &cmd.MigrateCommand{
 				Ui: ui,
@@ -37,6 +42,16 @@ func main() {
 				Ui: ui,
 			}, nil
 		},
+		"rollback": func() (cli.Command, error) {
+			return &cmd.RollbackCommand{
+				Ui: ui,
+			}, nil
+		},
+		"verify": func() (cli.Command, error) {
+			return &cmd.VerifyCommand{
+				Ui: ui,
+			}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &cmd.VersionCommand{
 				Ui:      ui,
@@ -0,0 +1,382 @@
+package tf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/magodo/tfadd/tfadd"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// GeneratorTfadd generates the config from the imported state with tfadd and the embedded azurerm schema
+	GeneratorTfadd = "tfadd"
+	// GeneratorTerraform generates the config with `terraform plan -generate-config-out`, which follows the installed provider
+	GeneratorTerraform = "terraform"
+)
+
+const (
+	filenameBatchImport    = "batch_import.tf"
+	filenameBatchGenerated = "batch_generated.tf"
+	batchPlanfile          = "tfplan_batch"
+)
+
+// ImportTarget is a resource which is imported by BatchImport, the address must be a resource in the root module
+type ImportTarget struct {
+	Address string
+	Id      string
+}
+
+// BatchImport imports all the targets by applying one plan of the `import` blocks, then converts their state to config
+// in a single `tfadd` pass. With GeneratorTerraform, the config is generated by the plan and nothing is imported.
+// The config is cached and returned by ImportAdd, so the imported resources are not imported again.
+// The config in the working directory must not contain the resource blocks of the targets, because their config is generated
+// by `terraform plan -generate-config-out`. The plan is only applied if it imports the targets without any other change, the targets which
+// fail to plan or are planned with other changes are dropped from the batch and left to ImportAdd.
+func (t *Terraform) BatchImport(targets []ImportTarget) error {
+	t.importedConfigs = make(map[string]string)
+	if len(targets) == 0 {
+		return nil
+	}
+	_ = t.Init()
+
+	if t.Generator == GeneratorTerraform {
+		configs, err := t.generateConfig(targets)
+		for address, config := range configs {
+			t.importedConfigs[address] = config
+		}
+		return err
+	}
+
+	importFile := path.Join(t.GetWorkingDirectory(), filenameBatchImport)
+	generatedFile := path.Join(t.GetWorkingDirectory(), filenameBatchGenerated)
+	planFile := path.Join(t.GetWorkingDirectory(), batchPlanfile)
+	defer removeFiles(importFile, generatedFile, planFile)
+
+	// the targets which can't be planned are dropped, so one invalid resource doesn't fail the whole batch
+	targets, err := PlanImportBatch(targets, func(targets []ImportTarget) ([]string, error) {
+		// `terraform plan -generate-config-out` fails if the file exists
+		removeFiles(generatedFile, planFile)
+		if err := writeImportBlocks(importFile, targets); err != nil {
+			return nil, err
+		}
+		var logs bytes.Buffer
+		_, planErr := t.exec.PlanJSON(context.TODO(), &logs, tfexec.GenerateConfigOut(filenameBatchGenerated), tfexec.Out(batchPlanfile))
+		t.SetLogEnabled(true)
+		if planErr != nil {
+			// #nosec G304
+			generatedConfig, _ := os.ReadFile(generatedFile)
+			failed, err := FailedImportAddresses(logs.Bytes(), targets, generatedConfig)
+			if err != nil {
+				return nil, fmt.Errorf("planning the import of %d resources: %w, %v", len(targets), planErr, err)
+			}
+			return failed, nil
+		}
+		p, err := t.ShowPlanFile(batchPlanfile)
+		if err != nil {
+			return nil, err
+		}
+		return UnexpectedImportChanges(p), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the state is still converted when the apply fails, the targets that aren't imported fall back to ImportAdd
+	applyErr := t.exec.Apply(context.TODO(), tfexec.DirOrPlan(batchPlanfile))
+	if applyErr != nil {
+		log.Printf("[WARN] applying the import of %d resources: %+v", len(targets), applyErr)
+	}
+
+	state, err := t.exec.Show(context.TODO())
+	if err != nil {
+		return fmt.Errorf("reading the imported state: %w", err)
+	}
+	inState := make(map[string]bool)
+	if state != nil && state.Values != nil && state.Values.RootModule != nil {
+		for _, resource := range state.Values.RootModule.Resources {
+			inState[resource.Address] = true
+		}
+	}
+	addresses := make([]string, 0)
+	for _, target := range targets {
+		if inState[target.Address] {
+			addresses = append(addresses, target.Address)
+		}
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no resource is imported: %v", applyErr)
+	}
+
+	outputs, err := tfadd.StateForTargets(context.TODO(), t.exec, addresses, tfadd.Full(true))
+	if err != nil {
+		return fmt.Errorf("converting terraform state to config for %d resources: %w", len(addresses), err)
+	}
+	if len(outputs) != len(addresses) {
+		return fmt.Errorf("expect config of %d resources, but got %d", len(addresses), len(outputs))
+	}
+	for i, address := range addresses {
+		t.importedConfigs[address] = string(outputs[i])
+	}
+	return applyErr
+}
+
+// generateConfig generates the config of the targets by `terraform plan -generate-config-out` without importing them,
+// the config of the resources which are generated is returned even if the plan fails, e.g. the generated config is invalid.
+func (t *Terraform) generateConfig(targets []ImportTarget) (map[string]string, error) {
+	_ = t.Init()
+	importFile := path.Join(t.GetWorkingDirectory(), filenameBatchImport)
+	generatedFile := path.Join(t.GetWorkingDirectory(), filenameBatchGenerated)
+	defer removeFiles(importFile, generatedFile)
+	removeFiles(generatedFile)
+	if err := writeImportBlocks(importFile, targets); err != nil {
+		return nil, err
+	}
+
+	_, planErr := t.exec.Plan(context.TODO(), tfexec.GenerateConfigOut(filenameBatchGenerated))
+	// #nosec G304
+	data, err := os.ReadFile(generatedFile)
+	if err != nil {
+		if planErr != nil {
+			return nil, fmt.Errorf("generating config for %d resources: %w", len(targets), planErr)
+		}
+		return nil, err
+	}
+	res, err := GeneratedConfigs(data)
+	if err != nil {
+		return nil, err
+	}
+	if planErr != nil && len(res) != len(targets) {
+		return res, fmt.Errorf("generating config for %d resources: %w", len(targets), planErr)
+	}
+	return res, nil
+}
+
+// GeneratedConfigs splits the config generated by `terraform plan -generate-config-out` into the config of each resource,
+// the key is the resource address. The attributes which are set to `null` are removed.
+func GeneratedConfigs(data []byte) (map[string]string, error) {
+	f, diags := hclwrite.ParseConfig(data, filenameBatchGenerated, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing the generated config: %s", diags.Error())
+	}
+	res := make(map[string]string)
+	for _, block := range f.Body().Blocks() {
+		if block.Type() != "resource" || len(block.Labels()) != 2 {
+			continue
+		}
+		removeNullAttributes(block.Body())
+		newFile := hclwrite.NewEmptyFile()
+		newFile.Body().AppendBlock(block)
+		res[fmt.Sprintf("%s.%s", block.Labels()[0], block.Labels()[1])] = string(hclwrite.Format(newFile.Bytes()))
+	}
+	return res, nil
+}
+
+// PlanImportBatch plans the import of `targets` by `plan`, which returns the addresses of the targets that can't be imported in the batch.
+// Those targets are dropped and the rest are planned again, until `plan` returns no address. It returns the targets in the last plan,
+// the dropped targets are left to be imported one by one. An error is returned if `plan` fails or all the targets are dropped.
+func PlanImportBatch(targets []ImportTarget, plan func(targets []ImportTarget) ([]string, error)) ([]ImportTarget, error) {
+	return planBatch(targets, func(target ImportTarget) string { return target.Address }, plan)
+}
+
+// planBatch plans `items` by `plan`, which returns the addresses of the items that fail in the batch. Those items are dropped and the rest
+// are planned again, until `plan` returns no address. It returns the items in the last plan.
+func planBatch[T any](items []T, addressOf func(item T) string, plan func(items []T) ([]string, error)) ([]T, error) {
+	for len(items) != 0 {
+		failed, err := plan(items)
+		if err != nil {
+			return nil, err
+		}
+		if len(failed) == 0 {
+			return items, nil
+		}
+		failedAddresses := make(map[string]bool)
+		for _, address := range failed {
+			failedAddresses[address] = true
+		}
+		remaining := make([]T, 0)
+		for _, item := range items {
+			if failedAddresses[addressOf(item)] {
+				log.Printf("[WARN] %s fails in the batch, it will be processed separately", addressOf(item))
+				continue
+			}
+			remaining = append(remaining, item)
+		}
+		if len(remaining) == len(items) {
+			return nil, fmt.Errorf("the plan fails on %v, which are not in the batch", failed)
+		}
+		items = remaining
+	}
+	return nil, fmt.Errorf("all the items fail in the batch")
+}
+
+// UnexpectedImportChanges returns the addresses of the resources which are planned with changes other than a no-op import
+func UnexpectedImportChanges(p *tfjson.Plan) []string {
+	res := make([]string, 0)
+	if p == nil {
+		return res
+	}
+	for _, resourceChange := range p.ResourceChanges {
+		if resourceChange == nil || resourceChange.Change == nil {
+			continue
+		}
+		if resourceChange.Change.Importing == nil || !resourceChange.Change.Actions.NoOp() {
+			res = append(res, resourceChange.Address)
+		}
+	}
+	return res
+}
+
+// FailedImportAddresses returns the addresses of the targets which the error diagnostics in `logs` belong to, `logs` is the output
+// of `terraform plan -json` for the import blocks of `targets` and `generatedConfig` is the config generated by the plan.
+// A diagnostic belongs to the resource of its address, or the import block or generated resource block which contains its range.
+// An error is returned if any error diagnostic doesn't belong to a target.
+func FailedImportAddresses(logs []byte, targets []ImportTarget, generatedConfig []byte) ([]string, error) {
+	importConfig, err := ImportBlocks(targets)
+	if err != nil {
+		return nil, err
+	}
+	importBlocks := make([]*hclsyntax.Block, 0)
+	if f, diags := hclsyntax.ParseConfig(importConfig, filenameBatchImport, hcl.InitialPos); !diags.HasErrors() {
+		importBlocks = f.Body.(*hclsyntax.Body).Blocks
+	}
+	generatedBlocks := make([]*hclsyntax.Block, 0)
+	if f, diags := hclsyntax.ParseConfig(generatedConfig, filenameBatchGenerated, hcl.InitialPos); !diags.HasErrors() {
+		generatedBlocks = f.Body.(*hclsyntax.Body).Blocks
+	}
+	isTarget := make(map[string]bool)
+	for _, target := range targets {
+		isTarget[target.Address] = true
+	}
+
+	return diagnosticAddresses(logs, func(diagnostic tfjson.Diagnostic) string {
+		address := ""
+		switch {
+		case isTarget[diagnostic.Address]:
+			address = diagnostic.Address
+		case diagnostic.Range != nil && filepath.Base(diagnostic.Range.Filename) == filenameBatchImport:
+			for i, block := range importBlocks {
+				if containsLine(block.Range(), diagnostic.Range.Start.Line) && i < len(targets) {
+					address = targets[i].Address
+				}
+			}
+		case diagnostic.Range != nil && filepath.Base(diagnostic.Range.Filename) == filenameBatchGenerated:
+			address = blockAddressAt(generatedBlocks, "resource", diagnostic.Range.Start.Line)
+		}
+		if !isTarget[address] {
+			return ""
+		}
+		return address
+	})
+}
+
+// diagnosticAddresses returns the addresses which the error diagnostics in `logs` belong to, `logs` is the output of `terraform plan -json`.
+// `addressOf` returns the address which a diagnostic belongs to, or an empty string if it doesn't belong to any address, in which case
+// an error is returned.
+func diagnosticAddresses(logs []byte, addressOf func(diagnostic tfjson.Diagnostic) string) ([]string, error) {
+	res := make([]string, 0)
+	found := make(map[string]bool)
+	for _, line := range bytes.Split(logs, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		msg, err := tfjson.UnmarshalLogMessage(line)
+		if err != nil {
+			continue
+		}
+		diagnostic, ok := msg.(tfjson.DiagnosticLogMessage)
+		if !ok || diagnostic.Severity != tfjson.DiagnosticSeverityError {
+			continue
+		}
+		address := addressOf(diagnostic.Diagnostic)
+		if address == "" {
+			return nil, fmt.Errorf("the error doesn't belong to any resource in the batch: %s", diagnostic.Summary)
+		}
+		log.Printf("[WARN] %s: %s %s", address, diagnostic.Summary, diagnostic.Detail)
+		if !found[address] {
+			found[address] = true
+			res = append(res, address)
+		}
+	}
+	return res, nil
+}
+
+// blockAddressAt returns the address of the block of `blockType` which contains `line`, e.g. `azurerm_resource_group.example` for a
+// resource block and `data.azurerm_resource_group.example` for a data block
+func blockAddressAt(blocks []*hclsyntax.Block, blockType string, line int) string {
+	for _, block := range blocks {
+		if block.Type != blockType || len(block.Labels) != 2 || !containsLine(block.Range(), line) {
+			continue
+		}
+		if blockType == "data" {
+			return fmt.Sprintf("data.%s.%s", block.Labels[0], block.Labels[1])
+		}
+		return fmt.Sprintf("%s.%s", block.Labels[0], block.Labels[1])
+	}
+	return ""
+}
+
+func containsLine(r hcl.Range, line int) bool {
+	return r.Start.Line <= line && line <= r.End.Line
+}
+
+// removeNullAttributes removes the attributes which are set to `null`, terraform generates them for the optional attributes which are not set
+func removeNullAttributes(body *hclwrite.Body) {
+	for name, attr := range body.Attributes() {
+		if strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes())) == "null" {
+			body.RemoveAttribute(name)
+		}
+	}
+	for _, block := range body.Blocks() {
+		removeNullAttributes(block.Body())
+	}
+}
+
+func writeImportBlocks(filename string, targets []ImportTarget) error {
+	data, err := ImportBlocks(targets)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
+
+// ImportBlocks returns the config of the `import` blocks which import the targets, an error is returned if the address of a target
+// is not a resource in the root module, e.g. `azurerm_resource_group.example`
+func ImportBlocks(targets []ImportTarget) ([]byte, error) {
+	f := hclwrite.NewEmptyFile()
+	for _, target := range targets {
+		traversal, diags := hclsyntax.ParseTraversalAbs([]byte(target.Address), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid import address %q: %s", target.Address, diags.Error())
+		}
+		if len(traversal) != 2 || traversal.RootName() == "module" || traversal.RootName() == "data" {
+			return nil, fmt.Errorf("invalid import address %q: it must be a resource in the root module", target.Address)
+		}
+		if _, ok := traversal[1].(hcl.TraverseAttr); !ok {
+			return nil, fmt.Errorf("invalid import address %q: it must be a resource in the root module", target.Address)
+		}
+		importBlock := f.Body().AppendNewBlock("import", nil)
+		importBlock.Body().SetAttributeValue("id", cty.StringVal(target.Id))
+		importBlock.Body().SetAttributeTraversal("to", traversal)
+	}
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+func removeFiles(files ...string) {
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARN] removing %s: %+v", file, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package tf_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/tf"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_ImportBlocks(t *testing.T) {
+	targets := []tf.ImportTarget{
+		{Address: "azurerm_resource_group.example", Id: "/subscriptions/000/resourceGroups/example"},
+		{Address: "azapi_resource.example_0", Id: "/subscriptions/000/resourceGroups/example_0"},
+	}
+	expected := `import {
+  id = "/subscriptions/000/resourceGroups/example"
+  to = azurerm_resource_group.example
+}
+import {
+  id = "/subscriptions/000/resourceGroups/example_0"
+  to = azapi_resource.example_0
+}
+`
+	actual, err := tf.ImportBlocks(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, string(actual))
+	}
+
+	for _, address := range []string{"azurerm_resource_group", "azurerm_resource_group.example[0]", "module.child.azurerm_resource_group.example", "data.azurerm_resource_group.example", "azurerm_resource_group.example id"} {
+		if _, err := tf.ImportBlocks([]tf.ImportTarget{{Address: address, Id: "id"}}); err == nil {
+			t.Errorf("expect an error for address %q, but got nil", address)
+		}
+	}
+}
+
+func Test_UnexpectedImportChanges(t *testing.T) {
+	p := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "azurerm_resource_group.imported",
+				Change:  &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}, Importing: &tfjson.Importing{ID: "id1"}},
+			},
+			{
+				Address: "azurerm_resource_group.updated",
+				Change:  &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}, Importing: &tfjson.Importing{ID: "id2"}},
+			},
+			{
+				Address: "azurerm_resource_group.created",
+				Change:  &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}},
+			},
+		},
+	}
+	expected := []string{"azurerm_resource_group.updated", "azurerm_resource_group.created"}
+	if actual := tf.UnexpectedImportChanges(p); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expect %v, but got %v", expected, actual)
+	}
+}
+
+func Test_PlanImportBatch(t *testing.T) {
+	targets := []tf.ImportTarget{
+		{Address: "azurerm_resource_group.a", Id: "a"},
+		{Address: "azurerm_resource_group.b", Id: "b"},
+		{Address: "azurerm_resource_group.c", Id: "c"},
+	}
+
+	planned := make([][]string, 0)
+	actual, err := tf.PlanImportBatch(targets, func(targets []tf.ImportTarget) ([]string, error) {
+		addresses := make([]string, 0)
+		for _, target := range targets {
+			addresses = append(addresses, target.Address)
+		}
+		planned = append(planned, addresses)
+		if len(planned) == 1 {
+			return []string{"azurerm_resource_group.b"}, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []tf.ImportTarget{targets[0], targets[2]}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expect %v, but got %v", expected, actual)
+	}
+	expectedPlans := [][]string{
+		{"azurerm_resource_group.a", "azurerm_resource_group.b", "azurerm_resource_group.c"},
+		{"azurerm_resource_group.a", "azurerm_resource_group.c"},
+	}
+	if !reflect.DeepEqual(planned, expectedPlans) {
+		t.Fatalf("expect plans %v, but got %v", expectedPlans, planned)
+	}
+
+	if _, err := tf.PlanImportBatch(targets, func(targets []tf.ImportTarget) ([]string, error) {
+		return []string{targets[0].Address}, nil
+	}); err == nil {
+		t.Fatal("expect an error when all the targets are dropped, but got nil")
+	}
+	if _, err := tf.PlanImportBatch(targets, func(targets []tf.ImportTarget) ([]string, error) {
+		return []string{"azurerm_resource_group.other"}, nil
+	}); err == nil {
+		t.Fatal("expect an error when the failed resources are not targets, but got nil")
+	}
+}
+
+func Test_FailedImportAddresses(t *testing.T) {
+	targets := []tf.ImportTarget{
+		{Address: "azurerm_resource_group.a", Id: "a"},
+		{Address: "azurerm_resource_group.b", Id: "b"},
+		{Address: "azurerm_resource_group.c", Id: "c"},
+	}
+	generatedConfig := []byte(`resource "azurerm_resource_group" "a" {
+  name     = "a"
+  location = "westus"
+}
+
+resource "azurerm_resource_group" "c" {
+  name     = "c"
+  location = null
+}
+`)
+	logs := strings.Join([]string{
+		`{"@level":"info","@message":"Terraform 1.9.0","type":"version","terraform":"1.9.0","ui":"1.2"}`,
+		`{"@level":"warn","@message":"Warning: deprecated","type":"diagnostic","diagnostic":{"severity":"warning","summary":"deprecated","address":"azurerm_resource_group.a"}}`,
+		`{"@level":"error","@message":"Error: Cannot import non-existent remote object","type":"diagnostic","diagnostic":{"severity":"error","summary":"Cannot import non-existent remote object","range":{"filename":"batch_import.tf","start":{"line":5,"column":1,"byte":0},"end":{"line":5,"column":2,"byte":1}}}}`,
+		`{"@level":"error","@message":"Error: Missing required argument","type":"diagnostic","diagnostic":{"severity":"error","summary":"Missing required argument","range":{"filename":"batch_generated.tf","start":{"line":6,"column":1,"byte":0},"end":{"line":6,"column":2,"byte":1}}}}`,
+		`{"@level":"error","@message":"Error: invalid id","type":"diagnostic","diagnostic":{"severity":"error","summary":"invalid id","address":"azurerm_resource_group.c"}}`,
+	}, "\n")
+
+	actual, err := tf.FailedImportAddresses([]byte(logs), targets, generatedConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"azurerm_resource_group.b", "azurerm_resource_group.c"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expect %v, but got %v", expected, actual)
+	}
+
+	logs = `{"@level":"error","@message":"Error: Invalid provider configuration","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid provider configuration"}}`
+	if _, err := tf.FailedImportAddresses([]byte(logs), targets, generatedConfig); err == nil {
+		t.Fatal("expect an error for the diagnostic which doesn't belong to any target, but got nil")
+	}
+}
+
+func Test_GeneratedConfigs(t *testing.T) {
+	generatedConfig := []byte(`# __generated__ by Terraform
+resource "azurerm_resource_group" "a" {
+  location   = "westus"
+  managed_by = null
+  name       = "a"
+  tags       = null
+}
+
+resource "azurerm_resource_group" "b" {
+  location = "eastus"
+  name     = "b"
+}
+`)
+	actual, err := tf.GeneratedConfigs(generatedConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"azurerm_resource_group.a": `# __generated__ by Terraform
+resource "azurerm_resource_group" "a" {
+  location = "westus"
+  name     = "a"
+}
+`,
+		"azurerm_resource_group.b": `resource "azurerm_resource_group" "b" {
+  location = "eastus"
+  name     = "b"
+}
+`,
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expect %d configs, but got %d", len(expected), len(actual))
+	}
+	for address, config := range expected {
+		if actual[address] != config {
+			t.Errorf("expect config of %s:\n%s\nbut got:\n%s", address, config, actual[address])
+		}
+	}
+}
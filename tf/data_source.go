@@ -0,0 +1,171 @@
+package tf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	// dataSourceFolderName is the folder in the working directory which is used to read the data sources
+	dataSourceFolderName = "data_sources"
+	filenameDataSources  = "main.tf"
+)
+
+// dataSourceRead is a data source read by BatchReadDataSources, its block is renamed to `address` which is unique in the batch,
+// and `key` identifies the original block
+type dataSourceRead struct {
+	key     string
+	address string
+	block   *hclwrite.Block
+}
+
+// BatchReadDataSources reads the data sources `blocks` with one plan in a sub folder of the working directory, so the provider is started
+// once for all of them. Their attribute values are cached and returned by ReadDataSource, so the data sources are not read again.
+// The data sources which fail to read are dropped from the batch and left to ReadDataSource.
+func (t *Terraform) BatchReadDataSources(blocks []*hclwrite.Block) error {
+	if t.dataSourceValues == nil {
+		t.dataSourceValues = make(map[string]map[string]interface{})
+	}
+	reads := make([]dataSourceRead, 0)
+	added := make(map[string]bool)
+	for _, block := range blocks {
+		key := dataSourceKey(block)
+		if _, ok := t.dataSourceValues[key]; ok || added[key] || block.Type() != "data" || len(block.Labels()) != 2 {
+			continue
+		}
+		added[key] = true
+		f, diags := hclwrite.ParseConfig(block.BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+		if diags.HasErrors() || len(f.Body().Blocks()) != 1 {
+			return fmt.Errorf("parsing data source %s: %s", key, diags.Error())
+		}
+		read := f.Body().Blocks()[0]
+		label := fmt.Sprintf("data_source_%d", len(reads))
+		read.SetLabels([]string{block.Labels()[0], label})
+		reads = append(reads, dataSourceRead{
+			key:     key,
+			address: fmt.Sprintf("data.%s.%s", block.Labels()[0], label),
+			block:   read,
+		})
+	}
+	if len(reads) == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(t.GetWorkingDirectory(), dataSourceFolderName)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("creating data source workspace %q: %+v", dir, err)
+	}
+	dataTerraform, err := NewTerraform(dir, t.LogEnabled)
+	if err != nil {
+		return err
+	}
+	// reuse the provider configurations in the working directory
+	providerConfig := hclwrite.NewEmptyFile()
+	for _, b := range helper.ListHclBlocks(t.GetWorkingDirectory()) {
+		if b.Type() == "terraform" || b.Type() == "provider" {
+			providerConfig.Body().AppendBlock(b)
+			providerConfig.Body().AppendNewline()
+		}
+	}
+
+	_, err = planBatch(reads, func(read dataSourceRead) string { return read.address }, func(reads []dataSourceRead) ([]string, error) {
+		f := hclwrite.NewEmptyFile()
+		for _, read := range reads {
+			f.Body().AppendBlock(read.block)
+			f.Body().AppendNewline()
+		}
+		config := hclwrite.Format(append(append([]byte{}, providerConfig.Bytes()...), f.Bytes()...))
+		if err := os.WriteFile(filepath.Join(dir, filenameDataSources), config, 0600); err != nil {
+			return nil, err
+		}
+		if err := dataTerraform.Init(); err != nil {
+			return nil, fmt.Errorf("initializing data source workspace: %w", err)
+		}
+		removeFiles(path.Join(dir, planfile))
+		var logs bytes.Buffer
+		_, planErr := dataTerraform.exec.PlanJSON(context.TODO(), &logs, tfexec.Out(planfile))
+		dataTerraform.SetLogEnabled(true)
+		if planErr != nil {
+			failed, err := failedDataSourceAddresses(logs.Bytes(), reads, config)
+			if err != nil {
+				return nil, fmt.Errorf("reading %d data sources: %w, %v", len(reads), planErr, err)
+			}
+			return failed, nil
+		}
+		p, err := dataTerraform.ShowPlanFile(planfile)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]map[string]interface{})
+		if p != nil && p.PriorState != nil && p.PriorState.Values != nil && p.PriorState.Values.RootModule != nil {
+			for _, stateResource := range p.PriorState.Values.RootModule.Resources {
+				if stateResource.Mode == tfjson.DataResourceMode {
+					values[stateResource.Address] = stateResource.AttributeValues
+				}
+			}
+		}
+		for _, read := range reads {
+			if value, ok := values[read.address]; ok {
+				t.dataSourceValues[read.key] = value
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// ReadDataSource returns the attribute values of data source `block`, it's read in a sub folder of the working directory
+// unless it's read by BatchReadDataSources
+func (t *Terraform) ReadDataSource(block *hclwrite.Block) (map[string]interface{}, error) {
+	address := "data." + strings.Join(block.Labels(), ".")
+	if values, ok := t.dataSourceValues[dataSourceKey(block)]; ok {
+		return values, nil
+	}
+	if err := t.BatchReadDataSources([]*hclwrite.Block{block}); err != nil {
+		return nil, fmt.Errorf("reading data source %s: %w", address, err)
+	}
+	if values, ok := t.dataSourceValues[dataSourceKey(block)]; ok {
+		return values, nil
+	}
+	return nil, fmt.Errorf("data source %s is not found in the plan", address)
+}
+
+// failedDataSourceAddresses returns the addresses of the data sources which the error diagnostics in `logs` belong to, `config` is the
+// config of the data sources which is planned
+func failedDataSourceAddresses(logs []byte, reads []dataSourceRead, config []byte) ([]string, error) {
+	blocks := make([]*hclsyntax.Block, 0)
+	if f, diags := hclsyntax.ParseConfig(config, filenameDataSources, hcl.InitialPos); !diags.HasErrors() {
+		blocks = f.Body.(*hclsyntax.Body).Blocks
+	}
+	isRead := make(map[string]bool)
+	for _, read := range reads {
+		isRead[read.address] = true
+	}
+	return diagnosticAddresses(logs, func(diagnostic tfjson.Diagnostic) string {
+		address := diagnostic.Address
+		if !isRead[address] && diagnostic.Range != nil && filepath.Base(diagnostic.Range.Filename) == filenameDataSources {
+			address = blockAddressAt(blocks, "data", diagnostic.Range.Start.Line)
+		}
+		if !isRead[address] {
+			return ""
+		}
+		return address
+	})
+}
+
+// dataSourceKey identifies data source `block` by its config
+func dataSourceKey(block *hclwrite.Block) string {
+	return string(hclwrite.Format(block.BuildTokens(nil).Bytes()))
+}
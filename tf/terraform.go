@@ -2,6 +2,7 @@ package tf
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -9,19 +10,41 @@ import (
 	"path"
 	"strings"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-exec/tfexec"
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/magodo/tfadd/tfadd"
 )
 
 type Terraform struct {
-	exec             *tfexec.Terraform
-	LogEnabled       bool
+	exec       *tfexec.Terraform
+	LogEnabled bool
+	// Generator is the backend used to generate the config of the imported resources, the allowed values are GeneratorTfadd and GeneratorTerraform
+	Generator        string
 	workingDirectory string
+	// importedConfigs is the config of the resources imported by BatchImport, the key is the resource address
+	importedConfigs map[string]string
+	// dataSourceValues are the attribute values of the data sources read by BatchReadDataSources, the key is the config of the data source
+	dataSourceValues map[string]map[string]interface{}
 }
 
 const planfile = "tfplan"
 
+// ProviderAzapi and ProviderAzurerm are the addresses of the providers which the resources are migrated between
+const (
+	ProviderAzapi   = "registry.terraform.io/azure/azapi"
+	ProviderAzurerm = "registry.terraform.io/hashicorp/azurerm"
+)
+
+// movedBlockTerraformVersion is the minimum terraform version which supports moving resources across resource types by `moved` blocks
+var movedBlockTerraformVersion = version.Must(version.NewVersion("1.8.0"))
+
+// movedBlockProviderVersions are the minimum versions of the providers which implement MoveResourceState, the resources of the other providers
+// can be moved to them. The azurerm provider doesn't support moving resources from azapi provider, so it's not listed.
+var movedBlockProviderVersions = map[string]*version.Version{
+	ProviderAzapi: version.Must(version.NewVersion("1.13.0")),
+}
+
 func NewTerraform(workingDirectory string, logEnabled bool) (*Terraform, error) {
 	execPath, err := FindTerraform(context.Background())
 	if err != nil {
@@ -84,7 +107,42 @@ func (t *Terraform) Plan(varFile *string) (*tfjson.Plan, error) {
 	return p, err
 }
 
+// ShowPlanFile reads the plan saved by `terraform plan -out`
+func (t *Terraform) ShowPlanFile(planFile string) (*tfjson.Plan, error) {
+	t.SetLogEnabled(false)
+	p, err := t.exec.ShowPlanFile(context.TODO(), planFile)
+	t.SetLogEnabled(true)
+	return p, err
+}
+
+// ReadPlanJson reads the plan in the JSON format produced by `terraform show -json`
+func ReadPlanJson(filename string) (*tfjson.Plan, error) {
+	// #nosec G304
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var p tfjson.Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing plan %s: %w", filename, err)
+	}
+	return &p, nil
+}
+
 func (t *Terraform) ImportAdd(address string, id string) (string, error) {
+	if config, ok := t.importedConfigs[address]; ok {
+		return config, nil
+	}
+	if t.Generator == GeneratorTerraform {
+		configs, err := t.generateConfig([]ImportTarget{{Address: address, Id: id}})
+		if err != nil {
+			return "", err
+		}
+		if config, ok := configs[address]; ok {
+			return config, nil
+		}
+		return "", fmt.Errorf("config of resource %s is not generated", address)
+	}
 	_ = t.Init()
 	err := t.exec.Import(context.TODO(), address, id)
 	if err != nil {
@@ -100,11 +158,78 @@ func (t *Terraform) ImportAdd(address string, id string) (string, error) {
 	return string(outputs[0]), nil
 }
 
+// ImportedConfig returns the config of resource `address` which is imported by BatchImport
+func (t *Terraform) ImportedConfig(address string) (string, bool) {
+	config, ok := t.importedConfigs[address]
+	return config, ok
+}
+
+// ProvidersSchema returns the schemas of the providers used by the configuration
+func (t *Terraform) ProvidersSchema() (*tfjson.ProviderSchemas, error) {
+	_ = t.Init()
+	t.SetLogEnabled(false)
+	pschs, err := t.exec.ProvidersSchema(context.TODO())
+	t.SetLogEnabled(true)
+	return pschs, err
+}
+
+// SupportsMovedBlock returns true if the resources of the other providers can be moved to `provider` by `moved` blocks, see MovedBlockSupported
+func (t *Terraform) SupportsMovedBlock(provider string) bool {
+	t.SetLogEnabled(false)
+	tfVersion, providerVersions, err := t.exec.Version(context.TODO(), false)
+	t.SetLogEnabled(true)
+	if err != nil {
+		log.Printf("[WARN] detecting terraform version: %+v", err)
+	}
+	return MovedBlockSupported(provider, tfVersion, providerVersions)
+}
+
+// MovedBlockSupported returns true if the resources of the other providers can be moved to `provider` by `moved` blocks. It requires
+// terraform 1.8+ and the version of `provider` implements MoveResourceState. It returns false if either version is unknown, e.g. the
+// providers are not installed yet, because a `moved` block across resource types fails the whole apply if it's not supported.
+func MovedBlockSupported(provider string, tfVersion *version.Version, providerVersions map[string]*version.Version) bool {
+	minVersion, ok := movedBlockProviderVersions[provider]
+	if !ok || tfVersion == nil || tfVersion.LessThan(movedBlockTerraformVersion) {
+		return false
+	}
+	providerVersion := providerVersions[provider]
+	return providerVersion != nil && !providerVersion.LessThan(minVersion)
+}
+
 func (t *Terraform) Import(address string, id string) error {
 	_ = t.Init()
 	return t.exec.Import(context.TODO(), address, id)
 }
 
+// ImportWithVarFile imports resource `id` to `address` by `terraform import`, the variables in `varFile` are used to evaluate the config
+func (t *Terraform) ImportWithVarFile(address string, id string, varFile string) error {
+	_ = t.Init()
+	options := make([]tfexec.ImportOption, 0)
+	if varFile != "" {
+		options = append(options, tfexec.VarFile(varFile))
+	}
+	return t.exec.Import(context.TODO(), address, id, options...)
+}
+
+// StatePull returns the current state by `terraform state pull`
+func (t *Terraform) StatePull() (string, error) {
+	_ = t.Init()
+	t.SetLogEnabled(false)
+	state, err := t.exec.StatePull(context.TODO())
+	t.SetLogEnabled(true)
+	return state, err
+}
+
+// StatePush overwrites the state with the state file at `path` by `terraform state push -force`
+func (t *Terraform) StatePush(path string) error {
+	return t.exec.StatePush(context.TODO(), path, tfexec.Force(true))
+}
+
+// StateRm removes resource `address` from the state by `terraform state rm`, the remote object is not destroyed
+func (t *Terraform) StateRm(address string) error {
+	return t.exec.StateRm(context.TODO(), address)
+}
+
 func (t *Terraform) Apply() error {
 	return t.exec.Apply(context.TODO())
 }
@@ -0,0 +1,83 @@
+package tf_test
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/go-version"
+)
+
+func Test_MovedBlockSupported(t *testing.T) {
+	testcases := []struct {
+		Name             string
+		Provider         string
+		TerraformVersion string
+		ProviderVersions map[string]string
+		Expect           bool
+	}{
+		{
+			Name:             "azapi supports moved block",
+			Provider:         tf.ProviderAzapi,
+			TerraformVersion: "1.8.0",
+			ProviderVersions: map[string]string{tf.ProviderAzapi: "1.13.0"},
+			Expect:           true,
+		},
+		{
+			Name:             "azapi is too old",
+			Provider:         tf.ProviderAzapi,
+			TerraformVersion: "1.9.0",
+			ProviderVersions: map[string]string{tf.ProviderAzapi: "1.12.1"},
+			Expect:           false,
+		},
+		{
+			Name:             "terraform is too old",
+			Provider:         tf.ProviderAzapi,
+			TerraformVersion: "1.7.5",
+			ProviderVersions: map[string]string{tf.ProviderAzapi: "2.0.0"},
+			Expect:           false,
+		},
+		{
+			Name:             "azapi version is unknown",
+			Provider:         tf.ProviderAzapi,
+			TerraformVersion: "1.8.0",
+			Expect:           false,
+		},
+		{
+			Name:     "versions are unknown",
+			Provider: tf.ProviderAzapi,
+			Expect:   false,
+		},
+		{
+			Name:             "terraform version is unknown",
+			Provider:         tf.ProviderAzapi,
+			ProviderVersions: map[string]string{tf.ProviderAzapi: "2.0.0"},
+			Expect:           false,
+		},
+		{
+			Name:             "azurerm doesn't support moving resources from azapi",
+			Provider:         tf.ProviderAzurerm,
+			TerraformVersion: "1.9.0",
+			ProviderVersions: map[string]string{tf.ProviderAzurerm: "4.0.0"},
+			Expect:           false,
+		},
+		{
+			Name:     "azurerm version is unknown",
+			Provider: tf.ProviderAzurerm,
+			Expect:   false,
+		},
+	}
+
+	for _, tc := range testcases {
+		var tfVersion *version.Version
+		if tc.TerraformVersion != "" {
+			tfVersion = version.Must(version.NewVersion(tc.TerraformVersion))
+		}
+		providerVersions := make(map[string]*version.Version)
+		for provider, v := range tc.ProviderVersions {
+			providerVersions[provider] = version.Must(version.NewVersion(v))
+		}
+		if actual := tf.MovedBlockSupported(tc.Provider, tfVersion, providerVersions); actual != tc.Expect {
+			t.Errorf("%s: expect %v, but got %v", tc.Name, tc.Expect, actual)
+		}
+	}
+}
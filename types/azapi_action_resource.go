@@ -0,0 +1,162 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+var _ AzureResource = &AzapiActionResource{}
+
+// actionsExposedByAzurerm are the read-only actions whose results are exposed by the azurerm data sources as computed attributes,
+// the key is `<resource type>/<action>` in lower case
+var actionsExposedByAzurerm = map[string]bool{
+	"microsoft.appconfiguration/configurationstores/listkeys":           true,
+	"microsoft.batch/batchaccounts/listkeys":                            true,
+	"microsoft.cache/redis/listkeys":                                    true,
+	"microsoft.cognitiveservices/accounts/listkeys":                     true,
+	"microsoft.containerregistry/registries/listcredentials":            true,
+	"microsoft.documentdb/databaseaccounts/listkeys":                    true,
+	"microsoft.documentdb/databaseaccounts/listconnectionstrings":       true,
+	"microsoft.eventhub/namespaces/authorizationrules/listkeys":         true,
+	"microsoft.maps/accounts/listkeys":                                  true,
+	"microsoft.notificationhubs/namespaces/authorizationrules/listkeys": true,
+	"microsoft.operationalinsights/workspaces/sharedkeys":               true,
+	"microsoft.relay/namespaces/authorizationrules/listkeys":            true,
+	"microsoft.search/searchservices/listadminkeys":                     true,
+	"microsoft.servicebus/namespaces/authorizationrules/listkeys":       true,
+	"microsoft.signalrservice/signalr/listkeys":                         true,
+	"microsoft.storage/storageaccounts/listkeys":                        true,
+	"microsoft.web/sites/config/publishingcredentials/list":             true,
+}
+
+// AzapiActionResource is an `azapi_resource_action` resource or data source. Only the read-only actions whose results are
+// exposed by azurerm can be migrated, they're replaced with the azurerm data source of the target resource.
+type AzapiActionResource struct {
+	Module       Module
+	Label        string
+	IsDataSource bool
+	ResourceId   string
+	ApiVersion   string
+	Action       string
+	Method       string
+	ResourceType string
+	Block        *hclwrite.Block
+	References   []Reference
+	outputs      []Output
+	Migrated     bool
+}
+
+func (r *AzapiActionResource) StateUpdateBlocks() []*hclwrite.Block {
+	if r.IsDataSource {
+		return []*hclwrite.Block{}
+	}
+	// the action doesn't manage any azure resource, so it's only removed from the state
+	return []*hclwrite.Block{newRemovedBlock(r.Module, "azapi_resource_action", r.Label)}
+}
+
+func (r *AzapiActionResource) Outputs() []Output {
+	res := make([]Output, 0)
+	res = append(res, r.outputs...)
+	res = sortOutputs(res)
+	res = append(res, Output{
+		OldName: r.OldAddress(nil),
+		NewName: r.NewAddress(nil),
+	})
+	return res
+}
+
+func (r *AzapiActionResource) GetModule() Module {
+	return r.Module
+}
+
+func (r *AzapiActionResource) MigratedBlock() *hclwrite.Block {
+	return r.Block
+}
+
+func (r *AzapiActionResource) IsMigrated() bool {
+	return r.Migrated
+}
+
+func (r *AzapiActionResource) GenerateNewConfig(terraform *tf.Terraform) error {
+	block, err := newAzurermDataSourceBlock(r.ResourceType, r.Label, r.ResourceId)
+	if err != nil {
+		return fmt.Errorf("generating config for %s: %w", r.OldAddress(nil), err)
+	}
+
+	values, err := terraform.ReadDataSource(block)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", r.NewAddress(nil), err)
+	}
+	outputs := matchOutputs(r.outputs, values, r.NewAddress(nil))
+	if len(outputs) != len(r.outputs) {
+		// the references to the unmatched outputs would be broken after migration
+		matched := make(map[string]bool)
+		for _, output := range outputs {
+			matched[output.OldName] = true
+		}
+		unmatched := make([]string, 0)
+		for _, output := range r.outputs {
+			if !matched[output.OldName] {
+				unmatched = append(unmatched, output.OldName)
+			}
+		}
+		sort.Strings(unmatched)
+		return fmt.Errorf("%s: outputs [%s] are not exposed by %s", r.OldAddress(nil), strings.Join(unmatched, ", "), r.NewAddress(nil))
+	}
+	r.outputs = outputs
+	r.Block = InjectReference(block, r.References)
+	r.Migrated = true
+	return nil
+}
+
+func (r *AzapiActionResource) dataSourceBlocks(_ *tf.Terraform) []*hclwrite.Block {
+	if block, err := newAzurermDataSourceBlock(r.ResourceType, r.Label, r.ResourceId); err == nil {
+		return []*hclwrite.Block{block}
+	}
+	return nil
+}
+
+func (r *AzapiActionResource) TargetProvider() string {
+	return "azurerm"
+}
+
+func (r *AzapiActionResource) CoverageCheck(_ bool) error {
+	if !r.HasAzurermEquivalent() {
+		return &NoEquivalentError{
+			Address: r.Module.Qualify(r.OldAddress(nil)),
+			Reason:  fmt.Sprintf("action %q of %s isn't exposed by azurerm", r.Action, ResourceTypeOfResourceId(r.ResourceId)),
+		}
+	}
+	return nil
+}
+
+// HasAzurermEquivalent returns true if the action's result is exposed by the azurerm data source of the target resource
+func (r *AzapiActionResource) HasAzurermEquivalent() bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, "POST") && !strings.EqualFold(r.Method, "GET") {
+		return false
+	}
+	return actionsExposedByAzurerm[strings.ToLower(ResourceTypeOfResourceId(r.ResourceId)+"/"+r.Action)]
+}
+
+func (r *AzapiActionResource) OldAddress(_ interface{}) string {
+	if r.IsDataSource {
+		return fmt.Sprintf("data.azapi_resource_action.%s", r.Label)
+	}
+	return fmt.Sprintf("azapi_resource_action.%s", r.Label)
+}
+
+func (r *AzapiActionResource) NewAddress(_ interface{}) string {
+	return fmt.Sprintf("data.%s.%s", r.ResourceType, r.Label)
+}
+
+func (r *AzapiActionResource) EmptyImportConfig() string {
+	return ""
+}
+
+func (r *AzapiActionResource) ImportTargets() []tf.ImportTarget {
+	return nil
+}
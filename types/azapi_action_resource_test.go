@@ -0,0 +1,236 @@
+package types_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_ListActionAndDataPlaneResourcesFromPlan(t *testing.T) {
+	planJson := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "azapi_resource_action.list_keys",
+      "mode": "managed",
+      "type": "azapi_resource_action",
+      "name": "list_keys",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "type": "Microsoft.Storage/storageAccounts@2023-01-01",
+          "resource_id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+          "action": "listKeys",
+          "method": "POST"
+        }
+      }
+    },
+    {
+      "address": "azapi_resource_action.restart",
+      "mode": "managed",
+      "type": "azapi_resource_action",
+      "name": "restart",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "type": "Microsoft.Web/sites@2022-03-01",
+          "resource_id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Web/sites/app",
+          "action": "restart",
+          "method": "POST"
+        }
+      }
+    },
+    {
+      "address": "azapi_data_plane_resource.secret",
+      "mode": "managed",
+      "type": "azapi_data_plane_resource",
+      "name": "secret",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "type": "Microsoft.KeyVault/vaults/secrets@7.4",
+          "parent_id": "kv.vault.azure.net",
+          "name": "secret",
+          "output": {
+            "id": "https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79"
+          }
+        }
+      }
+    },
+    {
+      "address": "azapi_data_plane_resource.dataset",
+      "mode": "managed",
+      "type": "azapi_data_plane_resource",
+      "name": "dataset",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "type": "Microsoft.Synapse/workspaces/datasets@2020-12-01",
+          "parent_id": "ws.dev.azuresynapse.net",
+          "name": "dataset"
+        }
+      }
+    }
+  ],
+  "configuration": {
+    "root_module": {}
+  }
+}`
+	var p tfjson.Plan
+	if err := json.Unmarshal([]byte(planJson), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	resources := types.ListResourcesFromPlan(&p)
+	if len(resources) != 4 {
+		t.Fatalf("expect 4 resources, but got %d", len(resources))
+	}
+	supported := map[string]bool{
+		"azapi_resource_action.list_keys":   true,
+		"azapi_resource_action.restart":     false,
+		"azapi_data_plane_resource.secret":  true,
+		"azapi_data_plane_resource.dataset": false,
+	}
+	for _, r := range resources {
+		expected, ok := supported[r.OldAddress(nil)]
+		if !ok {
+			t.Fatalf("unexpected resource %s", r.OldAddress(nil))
+		}
+		err := r.CoverageCheck(false)
+		if expected && err != nil {
+			t.Fatalf("expect %s to be supported, but got %v", r.OldAddress(nil), err)
+		}
+		var noEquivalentErr *types.NoEquivalentError
+		if !expected && !errors.As(err, &noEquivalentErr) {
+			t.Fatalf("expect %s to be unsupported, but got %v", r.OldAddress(nil), err)
+		}
+
+		if resource, ok := r.(*types.AzapiDataPlaneResource); ok && expected {
+			if resource.NewAddress(nil) != "azurerm_key_vault_secret.secret" {
+				t.Fatalf("expect new address azurerm_key_vault_secret.secret, but got %s", resource.NewAddress(nil))
+			}
+			if resource.ImportId() != "https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79" {
+				t.Fatalf("expect import id https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79, but got %s", resource.ImportId())
+			}
+		}
+	}
+}
+
+func Test_DataPlaneResourceImportId(t *testing.T) {
+	testcases := []struct {
+		Name         string
+		Type         string
+		ParentId     string
+		ResourceName string
+		Output       interface{}
+		ResourceType string
+		ImportId     string
+		// ExpectError is true if the version which is required by the import id is not found
+		ExpectError bool
+	}{
+		{
+			Name:         "key vault secret",
+			Type:         "Microsoft.KeyVault/vaults/secrets@7.4",
+			ParentId:     "kv.vault.azure.net",
+			ResourceName: "secret",
+			Output:       map[string]interface{}{"id": "https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79"},
+			ResourceType: "azurerm_key_vault_secret",
+			ImportId:     "https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79",
+		},
+		{
+			Name:         "key vault secret in azapi v1",
+			Type:         "Microsoft.KeyVault/vaults/secrets@7.4",
+			ParentId:     "https://kv.vault.azure.net/",
+			ResourceName: "secret",
+			Output:       `{"id":"https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79"}`,
+			ResourceType: "azurerm_key_vault_secret",
+			ImportId:     "https://kv.vault.azure.net/secrets/secret/4387e9f3d6e14c459867679a90fd0f79",
+		},
+		{
+			Name:         "key vault secret without exported id",
+			Type:         "Microsoft.KeyVault/vaults/secrets@7.4",
+			ParentId:     "kv.vault.azure.net",
+			ResourceName: "secret",
+			ResourceType: "azurerm_key_vault_secret",
+			ExpectError:  true,
+		},
+		{
+			Name:         "key vault key",
+			Type:         "Microsoft.KeyVault/vaults/keys@7.4",
+			ParentId:     "kv.vault.azure.net",
+			ResourceName: "key",
+			Output:       map[string]interface{}{"key": map[string]interface{}{"kid": "https://kv.vault.azure.net/keys/key/78deebed173b48e48f55abf87ed4cf71"}},
+			ResourceType: "azurerm_key_vault_key",
+			ImportId:     "https://kv.vault.azure.net/keys/key/78deebed173b48e48f55abf87ed4cf71",
+		},
+		{
+			Name:         "key vault key without exported kid",
+			Type:         "Microsoft.KeyVault/vaults/keys@7.4",
+			ParentId:     "kv.vault.azure.net",
+			ResourceName: "key",
+			Output:       map[string]interface{}{"id": "https://kv.vault.azure.net/keys/key/78deebed173b48e48f55abf87ed4cf71"},
+			ResourceType: "azurerm_key_vault_key",
+			ExpectError:  true,
+		},
+		{
+			Name:         "key vault certificate",
+			Type:         "Microsoft.KeyVault/vaults/certificates@7.4",
+			ParentId:     "kv.vault.azure.net",
+			ResourceName: "cert",
+			Output:       map[string]interface{}{"id": "https://kv.vault.azure.net/certificates/cert/f8a1e6d6a2a44bb8a2bb7c8c4c1c1a2b"},
+			ResourceType: "azurerm_key_vault_certificate",
+			ImportId:     "https://kv.vault.azure.net/certificates/cert/f8a1e6d6a2a44bb8a2bb7c8c4c1c1a2b",
+		},
+		{
+			Name:         "managed hsm key",
+			Type:         "Microsoft.KeyVault/managedHSMs/keys@7.4",
+			ParentId:     "hsm.managedhsm.azure.net",
+			ResourceName: "key",
+			ResourceType: "azurerm_key_vault_managed_hardware_security_module_key",
+			ImportId:     "https://hsm.managedhsm.azure.net/keys/key",
+		},
+		{
+			Name:         "app configuration key value",
+			Type:         "Microsoft.AppConfiguration/configurationStores/keyValues@1.0",
+			ParentId:     "appconf.azconfig.io",
+			ResourceName: "key",
+			ResourceType: "azurerm_app_configuration_key",
+			ImportId:     "https://appconf.azconfig.io/kv/key?label=",
+		},
+		{
+			Name:         "app configuration key value with label",
+			Type:         "Microsoft.AppConfiguration/configurationStores/keyValues@1.0",
+			ParentId:     "appconf.azconfig.io",
+			ResourceName: "key?label=prod",
+			ResourceType: "azurerm_app_configuration_key",
+			ImportId:     "https://appconf.azconfig.io/kv/key?label=prod",
+		},
+	}
+
+	for _, tc := range testcases {
+		r := types.NewAzapiDataPlaneResource(types.Module{}, "test", tc.Type, tc.ParentId, tc.ResourceName, tc.Output)
+		if r.ResourceType != tc.ResourceType {
+			t.Errorf("%s: expect resource type %s, but got %s", tc.Name, tc.ResourceType, r.ResourceType)
+		}
+		err := r.CoverageCheck(false)
+		if tc.ExpectError {
+			if err == nil {
+				t.Errorf("%s: expect an error for the unknown version, but got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expect no error, but got %v", tc.Name, err)
+		}
+		if r.ImportId() != tc.ImportId {
+			t.Errorf("%s: expect import id %s, but got %s", tc.Name, tc.ImportId, r.ImportId())
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var _ AzureResource = &AzapiDataPlaneResource{}
+
+type dataPlaneResourceMapping struct {
+	resourceType string
+	// versionProperty is the response property which holds the versioned id, e.g. `id` of a key vault secret. It's set if the azurerm
+	// import id contains the current version of the data plane resource.
+	versionProperty string
+	// importId builds the azurerm import id from the `parent_id`, `name` and current version of the data plane resource
+	importId func(parentId string, name string, version string) string
+}
+
+// dataPlaneResourceMappings are the data plane resources which have a direct azurerm counterpart, the key is the resource type in lower case
+var dataPlaneResourceMappings = map[string]dataPlaneResourceMapping{
+	"microsoft.appconfiguration/configurationstores/keyvalues": {
+		resourceType: "azurerm_app_configuration_key",
+		importId: func(parentId string, name string, _ string) string {
+			if strings.Contains(name, "?label=") {
+				return fmt.Sprintf("https://%s/kv/%s", parentId, name)
+			}
+			return fmt.Sprintf("https://%s/kv/%s?label=", parentId, name)
+		},
+	},
+	"microsoft.keyvault/vaults/certificates": {
+		resourceType:    "azurerm_key_vault_certificate",
+		versionProperty: "id",
+		importId: func(parentId string, name string, version string) string {
+			return fmt.Sprintf("https://%s/certificates/%s/%s", parentId, name, version)
+		},
+	},
+	"microsoft.keyvault/vaults/keys": {
+		resourceType:    "azurerm_key_vault_key",
+		versionProperty: "key.kid",
+		importId: func(parentId string, name string, version string) string {
+			return fmt.Sprintf("https://%s/keys/%s/%s", parentId, name, version)
+		},
+	},
+	"microsoft.keyvault/vaults/secrets": {
+		resourceType:    "azurerm_key_vault_secret",
+		versionProperty: "id",
+		importId: func(parentId string, name string, version string) string {
+			return fmt.Sprintf("https://%s/secrets/%s/%s", parentId, name, version)
+		},
+	},
+	"microsoft.keyvault/managedhsms/keys": {
+		resourceType: "azurerm_key_vault_managed_hardware_security_module_key",
+		importId: func(parentId string, name string, _ string) string {
+			return fmt.Sprintf("https://%s/keys/%s", parentId, name)
+		},
+	},
+}
+
+// AzapiDataPlaneResource is an `azapi_data_plane_resource`, it's migrated to the azurerm resource listed in `dataPlaneResourceMappings`
+type AzapiDataPlaneResource struct {
+	Module       Module
+	Label        string
+	Type         string
+	ApiVersion   string
+	ParentId     string
+	Name         string
+	ResourceType string
+	// Version is the current version of the data plane resource, it's only read if the azurerm import id contains it
+	Version    string
+	Block      *hclwrite.Block
+	References []Reference
+	outputs    []Output
+	Migrated   bool
+}
+
+// NewAzapiDataPlaneResource builds the data plane resource from its state, `typeValue` is in the format `<resource type>@<api-version>`,
+// and `output` is the exported response, which is a JSON string in azapi provider v1
+func NewAzapiDataPlaneResource(module Module, label string, typeValue string, parentId string, name string, output interface{}) *AzapiDataPlaneResource {
+	r := &AzapiDataPlaneResource{
+		Module:   module,
+		Label:    label,
+		Type:     typeValue,
+		ParentId: strings.TrimSuffix(strings.TrimPrefix(parentId, "https://"), "/"),
+		Name:     name,
+	}
+	if parts := strings.Split(typeValue, "@"); len(parts) == 2 {
+		r.Type = parts[0]
+		r.ApiVersion = parts[1]
+	}
+	if mapping, ok := dataPlaneResourceMappings[strings.ToLower(r.Type)]; ok {
+		r.ResourceType = mapping.resourceType
+		if mapping.versionProperty != "" {
+			r.Version = nestedItemVersion(output, mapping.versionProperty)
+		}
+	}
+	return r
+}
+
+// nestedItemVersion returns the version in the versioned id of a key vault nested item, e.g. `abc` of `https://kv.vault.azure.net/secrets/s/abc`,
+// the versioned id is the property at `path` of `output`
+func nestedItemVersion(output interface{}, path string) string {
+	if value, ok := output.(string); ok {
+		var outputObj interface{}
+		if err := json.Unmarshal([]byte(value), &outputObj); err == nil {
+			output = outputObj
+		}
+	}
+	id, _ := getPropValueMap(output, "")["."+path].(string)
+	u, err := url.Parse(id)
+	if err != nil {
+		return ""
+	}
+	// the path is `/<collection>/<name>/<version>`
+	if segments := strings.Split(strings.Trim(u.Path, "/"), "/"); len(segments) == 3 {
+		return segments[2]
+	}
+	return ""
+}
+
+func (r *AzapiDataPlaneResource) StateUpdateBlocks() []*hclwrite.Block {
+	removedBlock := newRemovedBlock(r.Module, "azapi_data_plane_resource", r.Label)
+	importBlock := hclwrite.NewBlock("import", nil)
+	importBlock.Body().SetAttributeValue("id", cty.StringVal(r.ImportId()))
+	importBlock.Body().SetAttributeTraversal("to", r.Module.Traversal(r.ResourceType, r.Label))
+	return []*hclwrite.Block{removedBlock, importBlock}
+}
+
+func (r *AzapiDataPlaneResource) Outputs() []Output {
+	res := make([]Output, 0)
+	res = append(res, r.outputs...)
+	res = append(res, Output{
+		OldName: r.OldAddress(nil) + ".id",
+		NewName: r.NewAddress(nil) + ".id",
+	})
+	res = sortOutputs(res)
+	res = append(res, Output{
+		OldName: r.OldAddress(nil),
+		NewName: r.NewAddress(nil),
+	})
+	return res
+}
+
+func (r *AzapiDataPlaneResource) GetModule() Module {
+	return r.Module
+}
+
+func (r *AzapiDataPlaneResource) MigratedBlock() *hclwrite.Block {
+	return r.Block
+}
+
+func (r *AzapiDataPlaneResource) IsMigrated() bool {
+	return r.Migrated
+}
+
+func (r *AzapiDataPlaneResource) GenerateNewConfig(terraform *tf.Terraform) error {
+	block, err := importAndGenerateConfig(terraform, r.NewAddress(nil), r.ImportId(), r.ResourceType, false)
+	if err != nil {
+		return err
+	}
+	valuePropMap := GetValuePropMap(block, r.NewAddress(nil))
+	unmatched := make([]string, 0)
+	for i, output := range r.outputs {
+		r.outputs[i].NewName = valuePropMap[output.GetStringValue()]
+		if r.outputs[i].NewName == "" {
+			unmatched = append(unmatched, output.OldName)
+		}
+	}
+	if len(unmatched) != 0 {
+		sort.Strings(unmatched)
+		return fmt.Errorf("%s: outputs [%s] are not found in %s", r.OldAddress(nil), strings.Join(unmatched, ", "), r.NewAddress(nil))
+	}
+	r.Block = InjectReference(block, r.References)
+	r.Migrated = true
+	return nil
+}
+
+func (r *AzapiDataPlaneResource) TargetProvider() string {
+	return "azurerm"
+}
+
+func (r *AzapiDataPlaneResource) CoverageCheck(_ bool) error {
+	if r.ResourceType == "" {
+		return &NoEquivalentError{
+			Address: r.Module.Qualify(r.OldAddress(nil)),
+			Reason:  fmt.Sprintf("data plane resource %s isn't supported by azurerm", r.Type),
+		}
+	}
+	if mapping := dataPlaneResourceMappings[strings.ToLower(r.Type)]; mapping.versionProperty != "" && r.Version == "" {
+		return fmt.Errorf("%s: the version of %s is not found, which is required to import %s, please add %q to `response_export_values` and apply it before migration",
+			r.Module.Qualify(r.OldAddress(nil)), r.Type, r.ResourceType, mapping.versionProperty)
+	}
+	return nil
+}
+
+// ImportId returns the id which is used to import the azurerm resource
+func (r *AzapiDataPlaneResource) ImportId() string {
+	mapping, ok := dataPlaneResourceMappings[strings.ToLower(r.Type)]
+	if !ok {
+		return ""
+	}
+	return mapping.importId(r.ParentId, r.Name, r.Version)
+}
+
+func (r *AzapiDataPlaneResource) OldAddress(_ interface{}) string {
+	return fmt.Sprintf("azapi_data_plane_resource.%s", r.Label)
+}
+
+func (r *AzapiDataPlaneResource) NewAddress(_ interface{}) string {
+	return fmt.Sprintf("%s.%s", r.ResourceType, r.Label)
+}
+
+func (r *AzapiDataPlaneResource) EmptyImportConfig() string {
+	return fmt.Sprintf("resource \"%s\" \"%s\" {}\n", r.ResourceType, r.Label)
+}
+
+func (r *AzapiDataPlaneResource) ImportTargets() []tf.ImportTarget {
+	return []tf.ImportTarget{{Address: r.NewAddress(nil), Id: r.ImportId()}}
+}
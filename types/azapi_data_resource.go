@@ -0,0 +1,119 @@
+package types
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Azure/aztfmigrate/azurerm/coverage"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+var _ AzureResource = &AzapiDataResource{}
+
+// AzapiDataResource is a `data.azapi_resource` which will be migrated to an azurerm data source
+type AzapiDataResource struct {
+	Module           Module
+	Label            string
+	ResourceId       string
+	ApiVersion       string
+	ResourceType     string
+	Block            *hclwrite.Block
+	References       []Reference
+	OutputProperties []string
+	outputs          []Output
+	Migrated         bool
+}
+
+func (r *AzapiDataResource) StateUpdateBlocks() []*hclwrite.Block {
+	// data sources are not stored in the state, so no need to update the state
+	return []*hclwrite.Block{}
+}
+
+func (r *AzapiDataResource) Outputs() []Output {
+	res := make([]Output, 0)
+	for _, prop := range []string{"id", "name"} {
+		res = append(res, Output{
+			OldName: r.OldAddress(nil) + "." + prop,
+			NewName: r.NewAddress(nil) + "." + prop,
+		})
+	}
+	res = append(res, r.outputs...)
+	res = sortOutputs(res)
+	res = append(res, Output{
+		OldName: r.OldAddress(nil),
+		NewName: r.NewAddress(nil),
+	})
+	return res
+}
+
+func (r *AzapiDataResource) GetModule() Module {
+	return r.Module
+}
+
+func (r *AzapiDataResource) MigratedBlock() *hclwrite.Block {
+	return r.Block
+}
+
+func (r *AzapiDataResource) IsMigrated() bool {
+	return r.Migrated
+}
+
+func (r *AzapiDataResource) GenerateNewConfig(terraform *tf.Terraform) error {
+	block, err := newAzurermDataSourceBlock(r.ResourceType, r.Label, r.ResourceId)
+	if err != nil {
+		return fmt.Errorf("generating config for %s: %w", r.OldAddress(nil), err)
+	}
+
+	values, err := terraform.ReadDataSource(block)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", r.NewAddress(nil), err)
+	}
+	r.outputs = matchOutputs(r.outputs, values, r.NewAddress(nil))
+	r.Block = InjectReference(block, r.References)
+	r.Migrated = true
+	return nil
+}
+
+func (r *AzapiDataResource) dataSourceBlocks(_ *tf.Terraform) []*hclwrite.Block {
+	if block, err := newAzurermDataSourceBlock(r.ResourceType, r.Label, r.ResourceId); err == nil {
+		return []*hclwrite.Block{block}
+	}
+	return nil
+}
+
+func (r *AzapiDataResource) TargetProvider() string {
+	return "azurerm"
+}
+
+func (r *AzapiDataResource) CoverageCheck(_ bool) error {
+	if os.Getenv("AZTF_MIGRATE_SKIP_COVERAGE_CHECK") == "true" {
+		return nil
+	}
+	idPattern, _ := GetIdPattern(r.ResourceId)
+	_, uncoveredGet := coverage.GetGetCoverage(r.OutputProperties, idPattern)
+	if len(uncoveredGet) != 0 {
+		return &CoverageError{
+			Address:                   r.Module.Qualify(r.OldAddress(nil)),
+			UncoveredInputProperties:  []string{},
+			UncoveredOutputProperties: uncoveredGet,
+		}
+	}
+	return nil
+}
+
+func (r *AzapiDataResource) OldAddress(_ interface{}) string {
+	return fmt.Sprintf("data.azapi_resource.%s", r.Label)
+}
+
+func (r *AzapiDataResource) NewAddress(_ interface{}) string {
+	return fmt.Sprintf("data.%s.%s", r.ResourceType, r.Label)
+}
+
+func (r *AzapiDataResource) EmptyImportConfig() string {
+	return ""
+}
+
+func (r *AzapiDataResource) ImportTargets() []tf.ImportTarget {
+	return nil
+}
@@ -3,7 +3,6 @@ package types
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/Azure/aztfmigrate/azurerm"
@@ -13,12 +12,12 @@ import (
 	"github.com/Azure/aztfmigrate/tf"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
-	"github.com/zclconf/go-cty/cty"
 )
 
 var _ AzureResource = &AzapiResource{}
 
 type AzapiResource struct {
+	Module           Module
 	Label            string
 	Instances        []Instance
 	ResourceType     string
@@ -29,10 +28,13 @@ type AzapiResource struct {
 	Migrated         bool
 }
 
+// StateUpdateBlocks returns the `removed` and `import` blocks, the azurerm provider doesn't support moving resources from azapi provider
 func (r *AzapiResource) StateUpdateBlocks() []*hclwrite.Block {
 	blocks := make([]*hclwrite.Block, 0)
-	blocks = append(blocks, r.removedBlock())
-	blocks = append(blocks, r.importBlock())
+	blocks = append(blocks, newRemovedBlock(r.Module, "azapi_resource", r.Label))
+	blocks = append(blocks, newImportBlock(r.Module, r.ResourceType, r.Label, r.Instances, r.IsMultipleResources(), func(instance Instance) string {
+		return instance.ResourceId
+	}))
 	return blocks
 }
 
@@ -48,6 +50,10 @@ func (r *AzapiResource) Outputs() []Output {
 	return res
 }
 
+func (r *AzapiResource) GetModule() Module {
+	return r.Module
+}
+
 func (r *AzapiResource) MigratedBlock() *hclwrite.Block {
 	return r.Block
 }
@@ -56,38 +62,6 @@ func (r *AzapiResource) IsMigrated() bool {
 	return r.Migrated
 }
 
-func (r *AzapiResource) importBlock() *hclwrite.Block {
-	importBlock := hclwrite.NewBlock("import", nil)
-	if r.IsMultipleResources() {
-		forEachMap := make(map[string]cty.Value)
-		for _, instance := range r.Instances {
-			switch v := instance.Index.(type) {
-			case string:
-				forEachMap[instance.ResourceId] = cty.StringVal(v)
-			default:
-				value, _ := strconv.ParseInt(fmt.Sprintf("%v", v), 10, 64)
-				forEachMap[instance.ResourceId] = cty.NumberIntVal(value)
-			}
-		}
-		importBlock.Body().SetAttributeValue("for_each", cty.MapVal(forEachMap))
-		importBlock.Body().SetAttributeTraversal("id", hcl.Traversal{hcl.TraverseRoot{Name: "each"}, hcl.TraverseAttr{Name: "key"}})
-		importBlock.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: r.ResourceType}, hcl.TraverseAttr{Name: fmt.Sprintf("%s[each.value]", r.Label)}})
-	} else {
-		importBlock.Body().SetAttributeValue("id", cty.StringVal(r.Instances[0].ResourceId))
-		importBlock.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: r.ResourceType}, hcl.TraverseAttr{Name: r.Label}})
-	}
-	return importBlock
-}
-
-func (r *AzapiResource) removedBlock() *hclwrite.Block {
-	removedBlock := hclwrite.NewBlock("removed", nil)
-	removedBlock.Body().SetAttributeTraversal("from", hcl.Traversal{hcl.TraverseRoot{Name: "azapi_resource"}, hcl.TraverseAttr{Name: r.Label}})
-	removedLifecycleBlock := hclwrite.NewBlock("lifecycle", nil)
-	removedLifecycleBlock.Body().SetAttributeValue("destroy", cty.BoolVal(false))
-	removedBlock.Body().AppendBlock(removedLifecycleBlock)
-	return removedBlock
-}
-
 func (r *AzapiResource) GenerateNewConfig(terraform *tf.Terraform) error {
 	if !r.IsMultipleResources() {
 		instance := r.Instances[0]
@@ -98,7 +72,7 @@ func (r *AzapiResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		r.Block = block
 		valuePropMap := GetValuePropMap(r.Block, r.NewAddress(nil))
 		for i, output := range r.Instances[0].Outputs {
-			r.Instances[0].Outputs[i].NewName = valuePropMap[output.GetStringValue()]
+			r.Instances[0].Outputs[i].NewName = r.outputNewName(output, instance, valuePropMap)
 		}
 		for i, instance := range r.Instances {
 			props := []string{"location", "tags", "identity", "identity.0", "identity.0.type", "identity.0.identity_ids", "id"}
@@ -114,8 +88,7 @@ func (r *AzapiResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		// import and build combined block
 		blocks := make([]*hclwrite.Block, 0)
 		for _, instance := range r.Instances {
-			instanceAddress := fmt.Sprintf("%s.%s_%v", r.ResourceType, r.Label, strings.ReplaceAll(fmt.Sprintf("%v", instance.Index), "/", "_"))
-			if block, err := importAndGenerateConfig(terraform, instanceAddress, instance.ResourceId, r.ResourceType, false); err == nil {
+			if block, err := importAndGenerateConfig(terraform, r.instanceAddress(instance), instance.ResourceId, r.ResourceType, false); err == nil {
 				blocks = append(blocks, block)
 			}
 		}
@@ -133,7 +106,7 @@ func (r *AzapiResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		for i, instance := range r.Instances {
 			valuePropMap := GetValuePropMap(blocks[i], r.NewAddress(instance.Index))
 			for j, output := range r.Instances[i].Outputs {
-				r.Instances[i].Outputs[j].NewName = valuePropMap[output.GetStringValue()]
+				r.Instances[i].Outputs[j].NewName = r.outputNewName(output, instance, valuePropMap)
 			}
 		}
 		for i, instance := range r.Instances {
@@ -155,6 +128,14 @@ func (r *AzapiResource) GenerateNewConfig(terraform *tf.Terraform) error {
 	return nil
 }
 
+// outputNewName returns the new name of the output, the mapping in the coverage report is preferred to the attribute holding the same value
+func (r *AzapiResource) outputNewName(output Output, instance Instance, valuePropMap map[string]string) string {
+	if newName := mappedOutputName(output.OldName, r.OldAddress(instance.Index), r.NewAddress(instance.Index), r.ResourceType, instance.ResourceId); newName != "" {
+		return newName
+	}
+	return valuePropMap[output.GetStringValue()]
+}
+
 func (r *AzapiResource) TargetProvider() string {
 	return "azurerm"
 }
@@ -168,8 +149,11 @@ func (r *AzapiResource) CoverageCheck(strictMode bool) error {
 	if strictMode {
 		azurermApiVersion := coverage.GetApiVersion(idPattern)
 		if azurermApiVersion != r.Instances[0].ApiVersion {
-			return fmt.Errorf("%s: api-versions are not matched, expect %s, got %s",
-				r.OldAddress(nil), r.Instances[0].ApiVersion, azurermApiVersion)
+			return &ApiVersionError{
+				Address:           r.Module.Qualify(r.OldAddress(nil)),
+				ApiVersion:        r.Instances[0].ApiVersion,
+				AzurermApiVersion: azurermApiVersion,
+			}
 		}
 	}
 
@@ -177,8 +161,11 @@ func (r *AzapiResource) CoverageCheck(strictMode bool) error {
 	_, uncoveredGet := coverage.GetGetCoverage(r.OutputProperties, idPattern)
 
 	if len(uncoveredGet)+len(uncoveredPut) != 0 {
-		return fmt.Errorf("%s: input properties not supported: [%v], output properties not supported: [%v]",
-			r.OldAddress(nil), strings.Join(uncoveredPut, ", "), strings.Join(uncoveredGet, ", "))
+		return &CoverageError{
+			Address:                   r.Module.Qualify(r.OldAddress(nil)),
+			UncoveredInputProperties:  uncoveredPut,
+			UncoveredOutputProperties: uncoveredGet,
+		}
 	}
 	return nil
 }
@@ -225,6 +212,25 @@ func (r *AzapiResource) EmptyImportConfig() string {
 	return config
 }
 
+func (r *AzapiResource) ImportTargets() []tf.ImportTarget {
+	res := make([]tf.ImportTarget, 0)
+	for _, instance := range r.Instances {
+		res = append(res, tf.ImportTarget{
+			Address: r.instanceAddress(instance),
+			Id:      instance.ResourceId,
+		})
+	}
+	return res
+}
+
+// instanceAddress returns the address which the instance is imported to, each instance of a multiple resource is imported separately
+func (r *AzapiResource) instanceAddress(instance Instance) string {
+	if !r.IsMultipleResources() {
+		return r.NewAddress(nil)
+	}
+	return fmt.Sprintf("%s.%s_%v", r.ResourceType, r.Label, strings.ReplaceAll(fmt.Sprintf("%v", instance.Index), "/", "_"))
+}
+
 func (r *AzapiResource) IsMultipleResources() bool {
 	return len(r.Instances) != 0 && r.Instances[0].Index != nil
 }
@@ -243,6 +249,8 @@ type Instance struct {
 	ApiVersion string
 	ResourceId string
 	Outputs    []Output
+	// Values are the attribute values of the instance in the state
+	Values interface{}
 }
 
 func importAndGenerateConfig(terraform *tf.Terraform, address string, id string, resourceType string, skipTune bool) (*hclwrite.Block, error) {
@@ -255,9 +263,10 @@ func importAndGenerateConfig(terraform *tf.Terraform, address string, id string,
 		return nil, fmt.Errorf("parsing the HCL generated by \"terraform add\" of %s: %s", address, diag.Error())
 	}
 
-	if !skipTune {
+	// the config generated by terraform follows the installed provider, so it's not tuned by the embedded schema
+	if !skipTune && terraform.Generator != tf.GeneratorTerraform {
 		rb := f.Body().Blocks()[0].Body()
-		sch := schema.ProviderSchemaInfo.ResourceSchemas[resourceType]
+		sch := schema.ResourceSchema(resourceType)
 		if err := azurerm.TuneHCLSchemaForResource(rb, sch); err != nil {
 			return nil, fmt.Errorf("tuning hcl config base on schema: %+v", err)
 		}
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// AzapiVersion1 is the major version of azapi provider whose `body` and `output` are JSON strings
+	AzapiVersion1 = 1
+	// AzapiVersion2 is the major version of azapi provider whose `body` and `output` are dynamic values
+	AzapiVersion2 = 2
+)
+
+// azapiV1Arguments are the arguments of azapi resources which are removed in azapi provider v2
+var azapiV1Arguments = []string{"ignore_body_changes", "removing_special_chars"}
+
+// NormalizeAzapiBlock converts azapi `block` to the style of azapi provider `version`, the `body` is an HCL object in v2 and
+// a `jsonencode` call in v1, and `response_export_values` lists exactly `exportValues`. The version 0 means the latest version.
+func NormalizeAzapiBlock(block *hclwrite.Block, version int, exportValues []string) {
+	if block == nil {
+		return
+	}
+	if attr := block.Body().GetAttribute("body"); attr != nil {
+		src := attr.Expr().BuildTokens(nil).Bytes()
+		if version == AzapiVersion1 {
+			if tokens := jsonencodeTokens(src); tokens != nil {
+				block.Body().SetAttributeRaw("body", tokens)
+			}
+		} else {
+			if tokens := objectTokens(src); tokens != nil {
+				block.Body().SetAttributeRaw("body", tokens)
+			}
+			for _, name := range azapiV1Arguments {
+				block.Body().RemoveAttribute(name)
+			}
+		}
+	}
+
+	if len(exportValues) == 0 {
+		block.Body().RemoveAttribute("response_export_values")
+		return
+	}
+	values := make([]cty.Value, 0)
+	for _, path := range exportValues {
+		values = append(values, cty.StringVal(path))
+	}
+	block.Body().SetAttributeValue("response_export_values", cty.ListVal(values))
+}
+
+// objectTokens returns the tokens of the HCL object which `body` expression `src` is encoded from, e.g. `{ a = 1 }` for
+// `jsonencode({ a = 1 })` or `"{\"a\":1}"`. It returns nil if `src` is not an encoded object.
+func objectTokens(src []byte) hclwrite.Tokens {
+	expr, diags := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok {
+		if call.Name == "jsonencode" && len(call.Args) == 1 {
+			return helper.GetTokensForExpression(string(call.Args[0].Range().SliceBytes(src)))
+		}
+		return nil
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(value.AsString()), &body); err != nil {
+		return nil
+	}
+	config, _ := helper.ToHclSearchReplace(body, nil, nil)
+	return helper.GetTokensForExpression(config)
+}
+
+// jsonencodeTokens returns the tokens of `jsonencode` call of `body` expression `src`, it returns nil if `src` is already a string
+func jsonencodeTokens(src []byte) hclwrite.Tokens {
+	expr, diags := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.FunctionCallExpr:
+		if e.Name == "jsonencode" {
+			return nil
+		}
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+		return nil
+	}
+	return helper.GetTokensForExpression(fmt.Sprintf("jsonencode(%s)", strings.TrimSpace(string(src))))
+}
+
+// AzapiOutputReference returns the reference to response property `path` of azapi resource `address`,
+// e.g. `azapi_resource.test.output.properties.vaultUri` in v2 and `jsondecode(azapi_resource.test.output).properties.vaultUri` in v1
+func AzapiOutputReference(address string, path string, version int) string {
+	if version == AzapiVersion1 {
+		return fmt.Sprintf("jsondecode(%s.output).%s", address, path)
+	}
+	return fmt.Sprintf("%s.output.%s", address, path)
+}
+
+// azapiOutputPath returns the response property which `reference` refers to in azapi resource `address`, it supports the references
+// returned by AzapiOutputReference. It returns empty string if `reference` isn't a reference to the response.
+func azapiOutputPath(reference string, address string) string {
+	for _, prefix := range []string{address + ".output.", fmt.Sprintf("jsondecode(%s.output).", address)} {
+		if path, ok := strings.CutPrefix(reference, prefix); ok {
+			return path
+		}
+	}
+	return ""
+}
+
+// exportValues returns the sorted response properties which `outputs` of azapi resource `addresses` refer to. The list elements
+// can't be exported individually, so the list is exported instead.
+func exportValues(outputs []Output, addresses []string) []string {
+	pathSet := make(map[string]bool)
+	for _, output := range outputs {
+		for _, address := range addresses {
+			path := azapiOutputPath(output.NewName, address)
+			if path == "" {
+				continue
+			}
+			segments := strings.Split(path, ".")
+			for i, segment := range segments {
+				if isIndex(segment) {
+					segments = segments[0:i]
+					break
+				}
+			}
+			if len(segments) != 0 {
+				pathSet[strings.Join(segments, ".")] = true
+			}
+			break
+		}
+	}
+	res := make([]string, 0)
+	for path := range pathSet {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
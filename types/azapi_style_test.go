@@ -0,0 +1,113 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_NormalizeAzapiBlock(t *testing.T) {
+	testcases := []struct {
+		Name         string
+		Input        string
+		Version      int
+		ExportValues []string
+		Expected     map[string]string
+	}{
+		{
+			Name: "jsonencode body in v2",
+			Input: `
+resource "azapi_resource" "test" {
+  type = "Microsoft.KeyVault/vaults@2023-07-01"
+  body = jsonencode({
+    properties = {
+      tenantId = var.tenant_id
+    }
+  })
+  ignore_body_changes    = []
+  response_export_values = ["*"]
+}
+`,
+			Version:      types.AzapiVersion2,
+			ExportValues: []string{"properties.vaultUri"},
+			Expected: map[string]string{
+				"body":                   "{\nproperties={\ntenantId=var.tenant_id\n}\n}",
+				"response_export_values": `["properties.vaultUri"]`,
+			},
+		},
+		{
+			Name: "string body in v2",
+			Input: `
+resource "azapi_resource" "test" {
+  type = "Microsoft.KeyVault/vaults@2023-07-01"
+  body = "{\"properties\":{\"enableRbacAuthorization\":true}}"
+}
+`,
+			Version: types.AzapiVersion2,
+			Expected: map[string]string{
+				"body": "{\nproperties={\nenableRbacAuthorization=true\n}\n}",
+			},
+		},
+		{
+			Name: "object body in v1",
+			Input: `
+resource "azapi_resource" "test" {
+  type = "Microsoft.KeyVault/vaults@2023-07-01"
+  body = {
+    properties = {
+      tenantId = var.tenant_id
+    }
+  }
+  response_export_values = ["*"]
+}
+`,
+			Version: types.AzapiVersion1,
+			Expected: map[string]string{
+				"body": "jsonencode({\nproperties={\ntenantId=var.tenant_id\n}\n})",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Logf("[DEBUG] testing %s", tc.Name)
+		f, diags := hclwrite.ParseConfig([]byte(tc.Input), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		block := f.Body().Blocks()[0]
+		types.NormalizeAzapiBlock(block, tc.Version, tc.ExportValues)
+		for _, name := range []string{"body", "response_export_values", "ignore_body_changes"} {
+			attr := block.Body().GetAttribute(name)
+			expected, ok := tc.Expected[name]
+			if !ok {
+				if attr != nil {
+					t.Fatalf("expect no attribute %s, but got %s", name, attr.Expr().BuildTokens(nil).Bytes())
+				}
+				continue
+			}
+			if attr == nil {
+				t.Fatalf("expect attribute %s, but got nil", name)
+			}
+			actual := strings.NewReplacer(" ", "", "\t", "").Replace(strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes())))
+			if actual != expected {
+				t.Fatalf("expect %s = %s, but got %s", name, expected, actual)
+			}
+		}
+		if _, diags := hclsyntax.ParseConfig(hclwrite.Format(f.Bytes()), "", hcl.InitialPos); diags.HasErrors() {
+			t.Fatalf("the normalized config is invalid: %s", diags.Error())
+		}
+	}
+}
+
+func Test_AzapiOutputReference(t *testing.T) {
+	if actual := types.AzapiOutputReference("azapi_resource.test", "properties.vaultUri", types.AzapiVersion2); actual != "azapi_resource.test.output.properties.vaultUri" {
+		t.Fatalf("expect the reference in v2, but got %s", actual)
+	}
+	if actual := types.AzapiOutputReference("azapi_resource.test", "properties.vaultUri", types.AzapiVersion1); actual != "jsondecode(azapi_resource.test.output).properties.vaultUri" {
+		t.Fatalf("expect the reference in v1, but got %s", actual)
+	}
+}
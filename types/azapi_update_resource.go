@@ -3,11 +3,9 @@ package types
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/Azure/aztfmigrate/azurerm/coverage"
 	"github.com/Azure/aztfmigrate/tf"
-	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/zclconf/go-cty/cty"
@@ -16,6 +14,7 @@ import (
 var _ AzureResource = &AzapiUpdateResource{}
 
 type AzapiUpdateResource struct {
+	Module           Module
 	ApiVersion       string
 	Label            string
 	OldLabel         string
@@ -46,6 +45,10 @@ func (r *AzapiUpdateResource) Outputs() []Output {
 	return res
 }
 
+func (r *AzapiUpdateResource) GetModule() Module {
+	return r.Module
+}
+
 func (r *AzapiUpdateResource) MigratedBlock() *hclwrite.Block {
 	return nil
 }
@@ -62,7 +65,10 @@ func (r *AzapiUpdateResource) GenerateNewConfig(terraform *tf.Terraform) error {
 	r.Block = block
 	valuePropMap := GetValuePropMap(r.Block, r.NewAddress(nil))
 	for i := range r.outputs {
-		r.outputs[i].NewName = valuePropMap[r.outputs[i].GetStringValue()]
+		r.outputs[i].NewName = mappedOutputName(r.outputs[i].OldName, r.OldAddress(nil), r.NewAddress(nil), r.ResourceType, r.Id)
+		if r.outputs[i].NewName == "" {
+			r.outputs[i].NewName = valuePropMap[r.outputs[i].GetStringValue()]
+		}
 	}
 	r.Block = InjectReference(r.Block, r.References)
 	r.Migrated = true
@@ -71,7 +77,7 @@ func (r *AzapiUpdateResource) GenerateNewConfig(terraform *tf.Terraform) error {
 
 func (r *AzapiUpdateResource) removedBlock() *hclwrite.Block {
 	removedBlock := hclwrite.NewBlock("removed", nil)
-	removedBlock.Body().SetAttributeTraversal("from", hcl.Traversal{hcl.TraverseRoot{Name: "azapi_update_resource"}, hcl.TraverseAttr{Name: r.OldLabel}})
+	removedBlock.Body().SetAttributeTraversal("from", r.Module.Traversal("azapi_update_resource", r.OldLabel))
 	removedLifecycleBlock := hclwrite.NewBlock("lifecycle", nil)
 	removedLifecycleBlock.Body().SetAttributeValue("destroy", cty.BoolVal(false))
 	removedBlock.Body().AppendBlock(removedLifecycleBlock)
@@ -90,8 +96,11 @@ func (r *AzapiUpdateResource) CoverageCheck(strictMode bool) error {
 	if strictMode {
 		azurermApiVersion := coverage.GetApiVersion(idPattern)
 		if azurermApiVersion != r.ApiVersion {
-			return fmt.Errorf("%s: api-versions are not matched, expect %s, got %s",
-				r.OldAddress(nil), r.ApiVersion, azurermApiVersion)
+			return &ApiVersionError{
+				Address:           r.Module.Qualify(r.OldAddress(nil)),
+				ApiVersion:        r.ApiVersion,
+				AzurermApiVersion: azurermApiVersion,
+			}
 		}
 	}
 
@@ -99,8 +108,11 @@ func (r *AzapiUpdateResource) CoverageCheck(strictMode bool) error {
 	_, uncoveredGet := coverage.GetGetCoverage(r.OutputProperties, idPattern)
 
 	if len(uncoveredGet)+len(uncoveredPut) != 0 {
-		return fmt.Errorf("%s: input properties not supported: [%v], output properties not supported: [%v]",
-			r.OldAddress(nil), strings.Join(uncoveredPut, ", "), strings.Join(uncoveredGet, ", "))
+		return &CoverageError{
+			Address:                   r.Module.Qualify(r.OldAddress(nil)),
+			UncoveredInputProperties:  uncoveredPut,
+			UncoveredOutputProperties: uncoveredGet,
+		}
 	}
 	return nil
 }
@@ -116,3 +128,7 @@ func (r *AzapiUpdateResource) NewAddress(_ interface{}) string {
 func (r *AzapiUpdateResource) EmptyImportConfig() string {
 	return fmt.Sprintf("resource \"%s\" \"%s\" {}\n", r.ResourceType, r.Label)
 }
+
+func (r *AzapiUpdateResource) ImportTargets() []tf.ImportTarget {
+	return []tf.ImportTarget{{Address: r.NewAddress(nil), Id: r.Id}}
+}
@@ -11,10 +11,14 @@ type AzureResource interface {
 	TargetProvider() string
 	OldAddress(index interface{}) string
 	NewAddress(index interface{}) string
+	// GetModule returns the module which the resource belongs to, the addresses above are relative to this module
+	GetModule() Module
 
 	CoverageCheck(strictMode bool) error
 	GenerateNewConfig(terraform *tf.Terraform) error
 	EmptyImportConfig() string
+	// ImportTargets returns the resources imported by GenerateNewConfig, they're imported together before generating the config
+	ImportTargets() []tf.ImportTarget
 
 	StateUpdateBlocks() []*hclwrite.Block
 	MigratedBlock() *hclwrite.Block
@@ -26,6 +30,12 @@ type AzureResource interface {
 type Reference struct {
 	Name  string
 	Value interface{}
+	// Path is the path of the expression in the original resource, e.g. `body.properties.sku.name`, it's only set for
+	// the expressions listed by ListExpressionReferences, whose Name is the source of the expression
+	Path string
+	// NewPath is the path of the attribute in the migrated resource which the expression maps to, e.g. `sku_name`.
+	// It's found by the coverage report, and the expression is only injected into this attribute if it's set.
+	NewPath string
 }
 
 func (r Reference) GetStringValue() string {
@@ -0,0 +1,101 @@
+package types_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_ImportTargets(t *testing.T) {
+	resourceGroupId := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	testcases := []struct {
+		Resource types.AzureResource
+		Expected map[string]string
+	}{
+		{
+			Resource: &types.AzapiResource{
+				Label:        "test",
+				ResourceType: "azurerm_resource_group",
+				Instances:    []types.Instance{{ResourceId: resourceGroupId}},
+			},
+			Expected: map[string]string{
+				"azurerm_resource_group.test": resourceGroupId,
+			},
+		},
+		{
+			Resource: &types.AzapiResource{
+				Label:        "test",
+				ResourceType: "azurerm_resource_group",
+				Instances: []types.Instance{
+					{Index: "a", ResourceId: resourceGroupId + "a"},
+					{Index: "b", ResourceId: resourceGroupId + "b"},
+				},
+			},
+			Expected: map[string]string{
+				"azurerm_resource_group.test_a": resourceGroupId + "a",
+				"azurerm_resource_group.test_b": resourceGroupId + "b",
+			},
+		},
+		{
+			Resource: &types.AzurermResource{
+				OldLabel:        "test",
+				NewLabel:        "resourceGroup",
+				NewResourceType: "azapi_resource",
+				Instances: []types.Instance{
+					{Index: 0, ResourceId: resourceGroupId + "0"},
+					{Index: 1, ResourceId: resourceGroupId + "1"},
+				},
+			},
+			Expected: map[string]string{
+				"azapi_resource.resourceGroup_0": resourceGroupId + "0",
+				"azapi_resource.resourceGroup_1": resourceGroupId + "1",
+			},
+		},
+		{
+			Resource: &types.AzapiUpdateResource{
+				Label:        "test",
+				ResourceType: "azurerm_resource_group",
+				Id:           resourceGroupId,
+			},
+			Expected: map[string]string{
+				"azurerm_resource_group.test": resourceGroupId,
+			},
+		},
+		{
+			Resource: &types.AzapiDataResource{
+				Label:        "test",
+				ResourceType: "azurerm_resource_group",
+				ResourceId:   resourceGroupId,
+			},
+			Expected: map[string]string{},
+		},
+	}
+
+	for _, testcase := range testcases {
+		actual := make(map[string]string)
+		for _, target := range testcase.Resource.ImportTargets() {
+			actual[target.Address] = target.Id
+		}
+		if !reflect.DeepEqual(actual, testcase.Expected) {
+			t.Fatalf("expect import targets %v, but got %v", testcase.Expected, actual)
+		}
+
+		// the resources which aren't imported by the batch import are imported to the empty blocks one by one
+		f, diags := hclwrite.ParseConfig([]byte(testcase.Resource.EmptyImportConfig()), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		addresses := make(map[string]string)
+		for _, block := range f.Body().Blocks() {
+			address := fmt.Sprintf("%s.%s", block.Labels()[0], block.Labels()[1])
+			addresses[address] = testcase.Expected[address]
+		}
+		if !reflect.DeepEqual(addresses, testcase.Expected) {
+			t.Fatalf("expect empty blocks of %v, but got %v", testcase.Expected, addresses)
+		}
+	}
+}
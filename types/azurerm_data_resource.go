@@ -0,0 +1,147 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/Azure/aztfmigrate/azurerm/coverage"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var _ AzureResource = &AzurermDataResource{}
+
+// AzurermDataResource is an azurerm data source which will be migrated to a `data.azapi_resource`
+type AzurermDataResource struct {
+	Module          Module
+	OldResourceType string
+	OldLabel        string
+	NewLabel        string
+	ResourceId      string
+	Block           *hclwrite.Block
+	References      []Reference
+	outputs         []Output
+	Migrated        bool
+	// AzapiVersion is the major version of azapi provider which the config is generated for, the latest version is used if it's 0
+	AzapiVersion int
+}
+
+func (r *AzurermDataResource) StateUpdateBlocks() []*hclwrite.Block {
+	// data sources are not stored in the state, so no need to update the state
+	return []*hclwrite.Block{}
+}
+
+func (r *AzurermDataResource) Outputs() []Output {
+	res := make([]Output, 0)
+	for _, prop := range []string{"id", "name"} {
+		res = append(res, Output{
+			OldName: r.OldAddress(nil) + "." + prop,
+			NewName: r.NewAddress(nil) + "." + prop,
+		})
+	}
+	for _, output := range r.outputs {
+		if output.OldName == r.OldAddress(nil)+".id" || output.OldName == r.OldAddress(nil)+".name" {
+			continue
+		}
+		res = append(res, output)
+	}
+	res = sortOutputs(res)
+	res = append(res, Output{
+		OldName: r.OldAddress(nil),
+		NewName: r.NewAddress(nil),
+	})
+	return res
+}
+
+func (r *AzurermDataResource) GetModule() Module {
+	return r.Module
+}
+
+func (r *AzurermDataResource) MigratedBlock() *hclwrite.Block {
+	return r.Block
+}
+
+func (r *AzurermDataResource) IsMigrated() bool {
+	return r.Migrated
+}
+
+func (r *AzurermDataResource) GenerateNewConfig(terraform *tf.Terraform) error {
+	block, err := r.dataSourceBlock()
+	if err != nil {
+		return fmt.Errorf("generating config for %s: %w", r.OldAddress(nil), err)
+	}
+
+	values, err := terraform.ReadDataSource(block)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", r.NewAddress(nil), err)
+	}
+	r.outputs = matchOutputs(r.outputs, values["output"], r.NewAddress(nil)+".output")
+	for i, output := range r.outputs {
+		r.outputs[i].NewName = AzapiOutputReference(r.NewAddress(nil), azapiOutputPath(output.NewName, r.NewAddress(nil)), r.AzapiVersion)
+	}
+	// all the properties are read to match the outputs, but only the referenced ones are exported
+	NormalizeAzapiBlock(block, r.AzapiVersion, exportValues(r.outputs, []string{r.NewAddress(nil)}))
+	r.Block = InjectReference(block, r.References)
+	r.Migrated = true
+	return nil
+}
+
+// dataSourceBlock returns the `data.azapi_resource` block which reads the resource with the api-version in the coverage report
+func (r *AzurermDataResource) dataSourceBlock() (*hclwrite.Block, error) {
+	idPattern, _ := GetIdPattern(r.ResourceId)
+	apiVersion := coverage.GetApiVersion(idPattern)
+	if apiVersion == "" {
+		return nil, fmt.Errorf("api-version of %s is not found", ResourceTypeOfResourceId(r.ResourceId))
+	}
+	return newDataSourceBlock(r.NewLabel, r.ResourceId, fmt.Sprintf("%s@%s", ResourceTypeOfResourceId(r.ResourceId), apiVersion))
+}
+
+func (r *AzurermDataResource) dataSourceBlocks(_ *tf.Terraform) []*hclwrite.Block {
+	if block, err := r.dataSourceBlock(); err == nil {
+		return []*hclwrite.Block{block}
+	}
+	return nil
+}
+
+// newDataSourceBlock returns the `data.azapi_resource` block named `label` which reads resource `id` of `typeValue`, e.g.
+// `Microsoft.KeyVault/vaults@2023-07-01`, all the properties in the response are exported.
+func newDataSourceBlock(label string, id string, typeValue string) (*hclwrite.Block, error) {
+	resourceId, err := arm.ParseResourceID(id)
+	if err != nil {
+		return nil, err
+	}
+	block := hclwrite.NewBlock("data", []string{"azapi_resource", label})
+	block.Body().SetAttributeValue("type", cty.StringVal(typeValue))
+	if resourceId.Parent != nil {
+		block.Body().SetAttributeValue("parent_id", cty.StringVal(resourceId.Parent.String()))
+	}
+	block.Body().SetAttributeValue("name", cty.StringVal(resourceId.Name))
+	block.Body().SetAttributeValue("response_export_values", cty.ListVal([]cty.Value{cty.StringVal("*")}))
+	return block, nil
+}
+
+func (r *AzurermDataResource) TargetProvider() string {
+	return "azapi"
+}
+
+func (r *AzurermDataResource) CoverageCheck(_ bool) error {
+	// all properties are supported by azapi data source, so no need to check
+	return nil
+}
+
+func (r *AzurermDataResource) OldAddress(_ interface{}) string {
+	return fmt.Sprintf("data.%s.%s", r.OldResourceType, r.OldLabel)
+}
+
+func (r *AzurermDataResource) NewAddress(_ interface{}) string {
+	return fmt.Sprintf("data.azapi_resource.%s", r.NewLabel)
+}
+
+func (r *AzurermDataResource) EmptyImportConfig() string {
+	return ""
+}
+
+func (r *AzurermDataResource) ImportTargets() []tf.ImportTarget {
+	return nil
+}
@@ -10,13 +10,13 @@ import (
 	"github.com/Azure/aztfmigrate/tf"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/gertd/go-pluralize"
-	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
 var _ AzureResource = &AzurermResource{}
 
 type AzurermResource struct {
+	Module          Module
 	OldLabel        string
 	NewLabel        string
 	OldResourceType string
@@ -25,17 +25,30 @@ type AzurermResource struct {
 	Instances       []Instance
 	References      []Reference
 	Migrated        bool
+	// AzapiVersion is the major version of azapi provider which the config is generated for, the latest version is used if it's 0
+	AzapiVersion int
+	// MovedSupported is true if the resource can be moved to the azapi resource by a `moved` block, otherwise it's removed and imported
+	MovedSupported bool
 }
 
 func (r *AzurermResource) StateUpdateBlocks() []*hclwrite.Block {
-	movedBlock := hclwrite.NewBlock("moved", nil)
-	movedBlock.Body().SetAttributeTraversal("from", hcl.Traversal{hcl.TraverseRoot{Name: r.OldResourceType}, hcl.TraverseAttr{Name: r.OldLabel}})
-	movedBlock.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: r.NewResourceType}, hcl.TraverseAttr{Name: r.NewLabel}})
+	if r.MovedSupported {
+		return []*hclwrite.Block{newMovedBlock(r.Module, r.OldResourceType, r.OldLabel, r.NewResourceType, r.NewLabel)}
+	}
 	blocks := make([]*hclwrite.Block, 0)
-	blocks = append(blocks, movedBlock)
+	blocks = append(blocks, newRemovedBlock(r.Module, r.OldResourceType, r.OldLabel))
+	blocks = append(blocks, newImportBlock(r.Module, r.NewResourceType, r.NewLabel, r.Instances, r.IsMultipleResources(), r.importId))
 	return blocks
 }
 
+// importId returns the id to import the instance to azapi resource, the api-version of the migrated config is appended to the resource id
+func (r *AzurermResource) importId(instance Instance) string {
+	if _, apiVersion, ok := strings.Cut(typeValueOf(r.Block), "@"); ok {
+		return fmt.Sprintf("%s?api-version=%s", instance.ResourceId, apiVersion)
+	}
+	return instance.ResourceId
+}
+
 func (r *AzurermResource) Outputs() []Output {
 	res := make([]Output, 0)
 	for _, instance := range r.Instances {
@@ -48,6 +61,10 @@ func (r *AzurermResource) Outputs() []Output {
 	return res
 }
 
+func (r *AzurermResource) GetModule() Module {
+	return r.Module
+}
+
 func (r *AzurermResource) MigratedBlock() *hclwrite.Block {
 	return r.Block
 }
@@ -65,10 +82,7 @@ func (r *AzurermResource) GenerateNewConfig(terraform *tf.Terraform) error {
 			return err
 		}
 		r.Block = block
-		valuePropMap := GetValuePropMap(r.Block, r.NewAddress(nil))
-		for i, output := range r.Instances[0].Outputs {
-			r.Instances[0].Outputs[i].NewName = valuePropMap[output.GetStringValue()]
-		}
+		r.resolveAttributeOutputs(terraform, 0, typeValueOf(block))
 		r.Migrated = true
 		log.Printf("[INFO] resource %s has migrated to %s", r.OldAddress(nil), r.NewAddress(nil))
 		r.Block = InjectReference(r.Block, r.References)
@@ -77,8 +91,7 @@ func (r *AzurermResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		log.Printf("[INFO] generating config...")
 		blocks := make([]*hclwrite.Block, 0)
 		for _, instance := range r.Instances {
-			instanceAddress := fmt.Sprintf("%s.%s_%v", r.NewResourceType, r.NewLabel, strings.ReplaceAll(fmt.Sprintf("%v", instance.Index), "/", "_"))
-			if block, err := importAndGenerateConfig(terraform, instanceAddress, instance.ResourceId, "", true); err == nil {
+			if block, err := importAndGenerateConfig(terraform, r.instanceAddress(instance), instance.ResourceId, "", true); err == nil {
 				blocks = append(blocks, block)
 			}
 		}
@@ -93,14 +106,13 @@ func (r *AzurermResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		}
 
 		r.Block = combinedBlock
-		for i, instance := range r.Instances {
-			valuePropMap := GetValuePropMap(blocks[i], r.NewAddress(instance.Index))
-			for j, output := range r.Instances[i].Outputs {
-				r.Instances[i].Outputs[j].NewName = valuePropMap[output.GetStringValue()]
+		for i := range r.Instances {
+			if i < len(blocks) {
+				r.resolveAttributeOutputs(terraform, i, typeValueOf(blocks[i]))
 			}
 		}
 		for i, instance := range r.Instances {
-			// TODO: improve this, azapi resource should use .output.xxx to access the output properties
+			// the response properties are referenced by the outputs above, the other attributes are referenced by the new address
 			r.Instances[i].Outputs = append(r.Instances[i].Outputs, Output{
 				OldName: r.OldAddress(instance.Index),
 				NewName: r.NewAddress(instance.Index),
@@ -116,11 +128,23 @@ func (r *AzurermResource) GenerateNewConfig(terraform *tf.Terraform) error {
 		r.Block = InjectReference(r.Block, r.References)
 	}
 
+	NormalizeAzapiBlock(r.Block, r.AzapiVersion, r.exportValues())
 	r.Block = sortAttributes(r.Block)
 	r.Migrated = true
 	return nil
 }
 
+// exportValues returns the response properties which are referenced by the outputs of all instances
+func (r *AzurermResource) exportValues() []string {
+	outputs := make([]Output, 0)
+	addresses := make([]string, 0)
+	for _, instance := range r.Instances {
+		outputs = append(outputs, instance.Outputs...)
+		addresses = append(addresses, r.NewAddress(instance.Index))
+	}
+	return exportValues(outputs, addresses)
+}
+
 func (r *AzurermResource) TargetProvider() string {
 	return "azapi"
 }
@@ -172,6 +196,25 @@ func (r *AzurermResource) EmptyImportConfig() string {
 	return config
 }
 
+func (r *AzurermResource) ImportTargets() []tf.ImportTarget {
+	res := make([]tf.ImportTarget, 0)
+	for _, instance := range r.Instances {
+		res = append(res, tf.ImportTarget{
+			Address: r.instanceAddress(instance),
+			Id:      instance.ResourceId,
+		})
+	}
+	return res
+}
+
+// instanceAddress returns the address which the instance is imported to, each instance of a multiple resource is imported separately
+func (r *AzurermResource) instanceAddress(instance Instance) string {
+	if !r.IsMultipleResources() {
+		return r.NewAddress(nil)
+	}
+	return fmt.Sprintf("%s.%s_%v", r.NewResourceType, r.NewLabel, strings.ReplaceAll(fmt.Sprintf("%v", instance.Index), "/", "_"))
+}
+
 func (r *AzurermResource) IsMultipleResources() bool {
 	return len(r.Instances) != 0 && r.Instances[0].Index != nil
 }
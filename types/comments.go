@@ -0,0 +1,143 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// attributeComments are the comments attached to an attribute, `Lead` are the comment lines above it and `Line` is the comment
+// at the end of its last line
+type attributeComments struct {
+	Lead []string
+	Line string
+}
+
+// carryOverBlockComments returns `blocks` whose resource and data blocks carry the comments of `original`, the nil blocks are skipped
+func carryOverBlockComments(original *hclwrite.Block, blocks []*hclwrite.Block) []*hclwrite.Block {
+	res := make([]*hclwrite.Block, 0)
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		if original != nil && (block.Type() == "resource" || block.Type() == "data") {
+			block = CarryOverComments(original, block)
+		}
+		res = append(res, block)
+	}
+	return res
+}
+
+// CarryOverComments returns the `migrated` block with the lead comments of the `original` block, and the comments of the original attributes
+// which map to the migrated attributes 1:1. An attribute maps to the migrated attribute with the same name, or the only migrated attribute
+// whose expression is the same as its expression. The nested blocks copied from the original block, e.g. `lifecycle`, keep their comments.
+func CarryOverComments(original *hclwrite.Block, migrated *hclwrite.Block) *hclwrite.Block {
+	lead := leadComments(original.BuildTokens(nil))
+
+	migratedAttrs := migrated.Body().Attributes()
+	expressionAttrs := make(map[string][]string)
+	for name, attr := range migratedAttrs {
+		expr := expressionSource(attr)
+		expressionAttrs[expr] = append(expressionAttrs[expr], name)
+	}
+	originalAttrs := original.Body().Attributes()
+	originalExpressions := make(map[string]int)
+	for _, attr := range originalAttrs {
+		originalExpressions[expressionSource(attr)]++
+	}
+
+	comments := make(map[string]attributeComments)
+	for name, attr := range originalAttrs {
+		tokens := attr.BuildTokens(nil)
+		c := attributeComments{
+			Lead: leadComments(tokens),
+			Line: lineComment(tokens),
+		}
+		if len(c.Lead) == 0 && c.Line == "" {
+			continue
+		}
+		if _, ok := migratedAttrs[name]; ok {
+			comments[name] = c
+			continue
+		}
+		expr := expressionSource(attr)
+		if candidates := expressionAttrs[expr]; len(candidates) == 1 && originalExpressions[expr] == 1 && originalAttrs[candidates[0]] == nil {
+			comments[candidates[0]] = c
+		}
+	}
+	if len(lead) == 0 && len(comments) == 0 {
+		return migrated
+	}
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(migrated)
+	src := hclwrite.Format(f.Bytes())
+	syntaxFile, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() || len(syntaxFile.Body.(*hclsyntax.Body).Blocks) != 1 {
+		return migrated
+	}
+	lines := strings.Split(string(src), "\n")
+	// the line numbers start from 1, `before` are the comment lines inserted before the line at the index
+	before := make(map[int][]string)
+	for name, attr := range syntaxFile.Body.(*hclsyntax.Body).Blocks[0].Body.Attributes {
+		c, ok := comments[name]
+		if !ok {
+			continue
+		}
+		start, end := attr.SrcRange.Start.Line-1, attr.SrcRange.End.Line-1
+		indent := lines[start][:len(lines[start])-len(strings.TrimLeft(lines[start], " \t"))]
+		for _, comment := range c.Lead {
+			before[start] = append(before[start], indent+comment)
+		}
+		if c.Line != "" {
+			lines[end] += " " + c.Line
+		}
+	}
+	res := append([]string{}, lead...)
+	for i, line := range lines {
+		res = append(res, before[i]...)
+		res = append(res, line)
+	}
+	if block := parseBlock([]byte(strings.Join(res, "\n"))); block != nil {
+		return block
+	}
+	return migrated
+}
+
+// leadComments returns the comment lines at the beginning of `tokens`
+func leadComments(tokens hclwrite.Tokens) []string {
+	res := make([]string, 0)
+	for _, token := range tokens {
+		if token.Type == hclsyntax.TokenNewline {
+			continue
+		}
+		if token.Type != hclsyntax.TokenComment {
+			break
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(token.Bytes), "\n"), "\n") {
+			res = append(res, strings.TrimSpace(line))
+		}
+	}
+	return res
+}
+
+// lineComment returns the comment at the end of `tokens`, which are the tokens of an attribute
+func lineComment(tokens hclwrite.Tokens) string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].Type == hclsyntax.TokenNewline || tokens[i].Type == hclsyntax.TokenEOF {
+			continue
+		}
+		if tokens[i].Type == hclsyntax.TokenComment {
+			return strings.TrimSpace(string(tokens[i].Bytes))
+		}
+		return ""
+	}
+	return ""
+}
+
+// expressionSource returns the expression of `attr` as it's written
+func expressionSource(attr *hclwrite.Attribute) string {
+	return strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes()))
+}
@@ -0,0 +1,140 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_ReplaceResourceBlockComments(t *testing.T) {
+	config := `# Resource groups of the workload
+
+resource "azurerm_resource_group" "first" {
+  name     = "first"
+  location = "westeurope"
+}
+
+# compliance: CIS-1.2, reviewed by the security team
+resource "azapi_resource" "test" {
+  type = "Microsoft.Storage/storageAccounts@2023-01-01"
+  # the name is reserved
+  name      = var.name # must be globally unique
+  parent_id = azurerm_resource_group.first.id
+  location  = "westeurope" // data residency
+  body = {
+    sku = {
+      name = var.sku # approved sku only
+    }
+  }
+
+  lifecycle {
+    # managed by policy
+    ignore_changes = [tags]
+  }
+}
+
+// the last resource group
+resource "azurerm_resource_group" "last" {
+  name     = "last"
+  location = "westeurope"
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fs := helper.OsFileSystem{}
+	original, err := types.GetResourceBlock(fs, dir, "azapi_resource.test")
+	if err != nil || original == nil {
+		t.Fatalf("expect the original block, but got %v", err)
+	}
+	newBlock := hclwrite.NewBlock("resource", []string{"azurerm_storage_account", "test"})
+	newBlock.Body().SetAttributeRaw("name", helper.GetTokensForExpression("var.name"))
+	newBlock.Body().SetAttributeRaw("resource_group_name", helper.GetTokensForExpression("azurerm_resource_group.first.name"))
+	newBlock.Body().SetAttributeRaw("location", helper.GetTokensForExpression(`"westeurope"`))
+	newBlock.Body().SetAttributeRaw("account_tier", helper.GetTokensForExpression("var.sku"))
+	for _, block := range original.Body().Blocks() {
+		newBlock.Body().AppendBlock(block)
+	}
+	if err := types.ReplaceResourceBlock(fs, dir, types.Module{}, "azapi_resource.test", []*hclwrite.Block{newBlock}, types.ReplaceOptions{Original: types.OriginalDelete}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the comments in the nested expression are not carried over, the attributes don't map 1:1
+	expected := `# Resource groups of the workload
+
+resource "azurerm_resource_group" "first" {
+  name     = "first"
+  location = "westeurope"
+}
+
+# compliance: CIS-1.2, reviewed by the security team
+resource "azurerm_storage_account" "test" {
+  # the name is reserved
+  name                = var.name # must be globally unique
+  resource_group_name = azurerm_resource_group.first.name
+  location            = "westeurope" // data residency
+  account_tier        = var.sku
+  lifecycle {
+    # managed by policy
+    ignore_changes = [tags]
+  }
+}
+
+// the last resource group
+resource "azurerm_resource_group" "last" {
+  name     = "last"
+  location = "westeurope"
+}
+`
+	actual, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func Test_CarryOverComments(t *testing.T) {
+	config := `# the storage account
+resource "azurerm_storage_account" "test" {
+  # must be globally unique
+  name             = var.name
+  account_tier     = var.tier // approved tier
+  replication_type = "LRS"
+  tags             = var.tags # owner tags
+  extra            = var.tags
+}
+`
+	f, diags := hclwrite.ParseConfig([]byte(config), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	migrated := hclwrite.NewBlock("resource", []string{"azapi_resource", "test"})
+	migrated.Body().SetAttributeRaw("type", helper.GetTokensForExpression(`"Microsoft.Storage/storageAccounts@2023-01-01"`))
+	migrated.Body().SetAttributeRaw("name", helper.GetTokensForExpression("var.name"))
+	migrated.Body().SetAttributeRaw("sku", helper.GetTokensForExpression("var.tier"))
+	migrated.Body().SetAttributeRaw("labels", helper.GetTokensForExpression("var.tags"))
+
+	// var.tags is used by two original attributes, so its comment is not carried over
+	expected := `# the storage account
+resource "azapi_resource" "test" {
+  type = "Microsoft.Storage/storageAccounts@2023-01-01"
+  # must be globally unique
+  name   = var.name
+  sku    = var.tier // approved tier
+  labels = var.tags
+}
+`
+	output := hclwrite.NewEmptyFile()
+	output.Body().AppendBlock(types.CarryOverComments(f.Body().Blocks()[0], migrated))
+	if actual := string(hclwrite.Format(output.Bytes())); actual != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CoverageError is returned by `CoverageCheck` when some properties used by the resource are not supported by the target provider
+type CoverageError struct {
+	Address                   string
+	UncoveredInputProperties  []string
+	UncoveredOutputProperties []string
+}
+
+func (e *CoverageError) Error() string {
+	return fmt.Sprintf("%s: input properties not supported: [%v], output properties not supported: [%v]",
+		e.Address, strings.Join(e.UncoveredInputProperties, ", "), strings.Join(e.UncoveredOutputProperties, ", "))
+}
+
+// ApiVersionError is returned by `CoverageCheck` in strict mode when the api-version used by the resource is different from the one used by the target provider
+type ApiVersionError struct {
+	Address           string
+	ApiVersion        string
+	AzurermApiVersion string
+}
+
+func (e *ApiVersionError) Error() string {
+	return fmt.Sprintf("%s: api-versions are not matched, expect %s, got %s", e.Address, e.ApiVersion, e.AzurermApiVersion)
+}
+
+// NoEquivalentError is returned by `CoverageCheck` when the target provider doesn't have an equivalent of the resource
+type NoEquivalentError struct {
+	Address string
+	Reason  string
+}
+
+func (e *NoEquivalentError) Error() string {
+	return fmt.Sprintf("%s: no equivalent in the target provider, %s", e.Address, e.Reason)
+}
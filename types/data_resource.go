@@ -0,0 +1,265 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Azure/aztfmigrate/azurerm/schema"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// listDataResourcesFromState returns the data sources which can be migrated, data sources are not in the resource changes, so they're read from the prior state
+func listDataResourcesFromState(p *tfjson.Plan, modules map[string]Module, refValueMap map[string]interface{}) []AzureResource {
+	res := make([]AzureResource, 0)
+	if p.PriorState == nil || p.PriorState.Values == nil {
+		return res
+	}
+	for _, stateResource := range listStateResources(p.PriorState.Values.RootModule) {
+		if stateResource.Mode != tfjson.DataResourceMode {
+			continue
+		}
+		if stateResource.Type != "azapi_resource" && stateResource.Type != "azapi_resource_action" && !strings.HasPrefix(stateResource.Type, "azurerm_") {
+			continue
+		}
+		moduleAddress := strings.TrimSuffix(stateResource.Address, "."+fmt.Sprintf("data.%s.%s", stateResource.Type, stateResource.Name))
+		if moduleAddress == stateResource.Address {
+			moduleAddress = ""
+		}
+		module, ok := modules[moduleAddress]
+		if !ok {
+			continue
+		}
+		if stateResource.Index != nil {
+			log.Printf("[WARN] data source %s uses `count` or `for_each`, which is not supported", module.Qualify(fmt.Sprintf("data.%s.%s", stateResource.Type, stateResource.Name)))
+			continue
+		}
+		if stateResource.Type == "azapi_resource_action" {
+			r := &AzapiActionResource{
+				Module:       module,
+				Label:        stateResource.Name,
+				IsDataSource: true,
+				ResourceId:   getStringAttribute(stateResource.AttributeValues, "resource_id"),
+				ApiVersion:   getApiVersion(stateResource.AttributeValues),
+				Action:       getStringAttribute(stateResource.AttributeValues, "action"),
+				Method:       getStringAttribute(stateResource.AttributeValues, "method"),
+			}
+			r.References = getReferencesForAddress(module, r.OldAddress(nil), p, refValueMap)
+			r.outputs = getOutputsForAddress(module, r.OldAddress(nil), refValueMap)
+			res = append(res, r)
+			continue
+		}
+		id := getId(stateResource.AttributeValues)
+		if stateResource.Type == "azapi_resource" {
+			r := &AzapiDataResource{
+				Module:     module,
+				Label:      stateResource.Name,
+				ResourceId: id,
+				ApiVersion: getApiVersion(stateResource.AttributeValues),
+			}
+			r.References = getReferencesForAddress(module, r.OldAddress(nil), p, refValueMap)
+			r.outputs = getOutputsForAddress(module, r.OldAddress(nil), refValueMap)
+			r.OutputProperties = make([]string, 0)
+			for _, output := range r.outputs {
+				r.OutputProperties = append(r.OutputProperties, strings.TrimPrefix(output.OldName, r.OldAddress(nil)+".output."))
+			}
+			res = append(res, r)
+			continue
+		}
+		if azureId, err := AzurermIdToAzureId(stateResource.Type, id); err == nil {
+			id = azureId
+		}
+		if _, err := arm.ParseResourceID(id); err != nil {
+			// some data sources don't represent an azure resource, e.g. azurerm_client_config
+			continue
+		}
+		r := &AzurermDataResource{
+			Module:          module,
+			OldResourceType: stateResource.Type,
+			OldLabel:        stateResource.Name,
+			NewLabel:        NewLabel(id, stateResource.Name),
+			ResourceId:      id,
+		}
+		r.References = getReferencesForAddress(module, r.OldAddress(nil), p, refValueMap)
+		r.outputs = getAttributesForAddress(module, r.OldAddress(nil), refValueMap)
+		res = append(res, r)
+	}
+	return res
+}
+
+func listStateResources(stateModule *tfjson.StateModule) []*tfjson.StateResource {
+	res := make([]*tfjson.StateResource, 0)
+	if stateModule == nil {
+		return res
+	}
+	res = append(res, stateModule.Resources...)
+	for _, childModule := range stateModule.ChildModules {
+		res = append(res, listStateResources(childModule)...)
+	}
+	return res
+}
+
+// getAttributesForAddress returns all attributes of `address` which is relative to `module`, the attribute names are relative to `module` too
+func getAttributesForAddress(module Module, address string, refValueMap map[string]interface{}) []Output {
+	res := make([]Output, 0)
+	for key, value := range refValueMap {
+		if strings.HasPrefix(key, module.Qualify(address)+".") {
+			res = append(res, Output{
+				OldName: strings.TrimPrefix(key, module.Qualify("")),
+				Value:   value,
+			})
+		}
+	}
+	return res
+}
+
+// dataSourceReader is implemented by the resources which read data sources to generate the config
+type dataSourceReader interface {
+	// dataSourceBlocks returns the data sources which are read by GenerateNewConfig
+	dataSourceBlocks(terraform *tf.Terraform) []*hclwrite.Block
+}
+
+// ReadDataSources reads the data sources of `resources` together before their config is generated, so each data source isn't read by
+// its own plan. The data sources which can't be read together are read again when the config is generated.
+func ReadDataSources(terraform *tf.Terraform, resources []AzureResource) {
+	blocks := make([]*hclwrite.Block, 0)
+	for _, r := range resources {
+		if reader, ok := r.(dataSourceReader); ok {
+			blocks = append(blocks, reader.dataSourceBlocks(terraform)...)
+		}
+	}
+	if len(blocks) == 0 {
+		return
+	}
+	log.Printf("[INFO] reading %d data sources...", len(blocks))
+	if err := terraform.BatchReadDataSources(blocks); err != nil {
+		log.Printf("[WARN] reading data sources in a batch, the data sources which aren't read will be read one by one: %+v", err)
+	}
+}
+
+// matchOutputs sets the new name of `outputs` to the attribute of `values` which holds the same value, outputs that don't have a match are dropped
+func matchOutputs(outputs []Output, values interface{}, prefix string) []Output {
+	if output, ok := values.(string); ok {
+		var outputObj interface{}
+		if err := json.Unmarshal([]byte(output), &outputObj); err == nil {
+			values = outputObj
+		}
+	}
+	valueRefMap := make(map[string]string)
+	for key, value := range getPropValueMap(values, prefix) {
+		stringValue := Output{Value: value}.GetStringValue()
+		// prefer the shortest path, so the result is stable when multiple attributes hold the same value
+		if existing, ok := valueRefMap[stringValue]; !ok || len(key) < len(existing) || len(key) == len(existing) && key < existing {
+			valueRefMap[stringValue] = key
+		}
+	}
+	res := make([]Output, 0)
+	for _, output := range outputs {
+		if newName := valueRefMap[output.GetStringValue()]; newName != "" {
+			output.NewName = newName
+			res = append(res, output)
+		}
+	}
+	return res
+}
+
+// sortOutputs sorts `outputs` so that the longer names are replaced first, e.g. `x.tenant_id` must be replaced before `x.tenant`
+func sortOutputs(outputs []Output) []Output {
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return len(outputs[i].OldName) > len(outputs[j].OldName)
+	})
+	return outputs
+}
+
+// newAzurermDataSourceBlock returns the azurerm data source block of `resourceType` named `label` which looks up resource `id`
+func newAzurermDataSourceBlock(resourceType string, label string, id string) (*hclwrite.Block, error) {
+	args, err := dataSourceArguments(resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	block := hclwrite.NewBlock("data", []string{resourceType, label})
+	argNames := make([]string, 0)
+	for argName := range args {
+		argNames = append(argNames, argName)
+	}
+	sort.Strings(argNames)
+	for _, argName := range argNames {
+		block.Body().SetAttributeValue(argName, args[argName])
+	}
+	return block, nil
+}
+
+// dataSourceArguments returns the arguments to look up resource `id` with azurerm data source `resourceType`.
+// There's no data source schema, so the required attributes of the resource schema are used instead.
+func dataSourceArguments(resourceType string, id string) (map[string]cty.Value, error) {
+	sch := schema.ResourceSchema(resourceType)
+	if sch == nil || sch.Block == nil {
+		return nil, fmt.Errorf("schema of %s is not found", resourceType)
+	}
+	resourceId, err := arm.ParseResourceID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]cty.Value)
+	for attrName, attr := range sch.Block.Attributes {
+		if !attr.Required {
+			continue
+		}
+		switch {
+		case attrName == "name":
+			res[attrName] = cty.StringVal(resourceId.Name)
+		case attrName == "resource_group_name":
+			res[attrName] = cty.StringVal(resourceId.ResourceGroupName)
+		case strings.HasSuffix(attrName, "_name"):
+			if parent := findParentId(resourceId, strings.TrimSuffix(attrName, "_name")); parent != nil {
+				res[attrName] = cty.StringVal(parent.Name)
+			}
+		case strings.HasSuffix(attrName, "_id"):
+			if parent := findParentId(resourceId, strings.TrimSuffix(attrName, "_id")); parent != nil {
+				res[attrName] = cty.StringVal(parent.String())
+			} else if resourceId.Parent != nil && resourceId.Parent.ResourceType.Namespace != "Microsoft.Resources" {
+				res[attrName] = cty.StringVal(resourceId.Parent.String())
+			}
+		}
+	}
+	if _, ok := res["name"]; !ok {
+		return nil, fmt.Errorf("%s doesn't support looking up resources by name", resourceType)
+	}
+	return res, nil
+}
+
+// findParentId returns the parent of `id` whose type is `name` in snake case, e.g. `virtual_network` matches `virtualNetworks`
+func findParentId(id *arm.ResourceID, name string) *arm.ResourceID {
+	for parent := id.Parent; parent != nil; parent = parent.Parent {
+		if len(parent.ResourceType.Types) == 0 {
+			continue
+		}
+		lastType := parent.ResourceType.Types[len(parent.ResourceType.Types)-1]
+		if toSnakeCase(pluralizeClient.Singular(lastType)) == name {
+			return parent
+		}
+	}
+	return nil
+}
+
+func toSnakeCase(input string) string {
+	res := strings.Builder{}
+	for i, c := range input {
+		if unicode.IsUpper(c) {
+			if i != 0 {
+				res.WriteRune('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		res.WriteRune(c)
+	}
+	return res.String()
+}
@@ -0,0 +1,89 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_ListDataResourcesFromPlan(t *testing.T) {
+	planJson := `{
+  "format_version": "1.2",
+  "prior_state": {
+    "format_version": "1.0",
+    "values": {
+      "root_module": {
+        "resources": [
+          {
+            "address": "data.azapi_resource.vnet",
+            "mode": "data",
+            "type": "azapi_resource",
+            "name": "vnet",
+            "provider_name": "registry.terraform.io/azure/azapi",
+            "values": {
+              "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+              "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+            }
+          },
+          {
+            "address": "data.azurerm_resource_group.test",
+            "mode": "data",
+            "type": "azurerm_resource_group",
+            "name": "test",
+            "provider_name": "registry.terraform.io/hashicorp/azurerm",
+            "values": {
+              "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg",
+              "name": "rg"
+            }
+          },
+          {
+            "address": "data.azurerm_client_config.current",
+            "mode": "data",
+            "type": "azurerm_client_config",
+            "name": "current",
+            "provider_name": "registry.terraform.io/hashicorp/azurerm",
+            "values": {
+              "id": "Y2xpZW50Q29uZmlncy9jbGllbnRJZD0=",
+              "tenant_id": "00000000-0000-0000-0000-000000000000"
+            }
+          }
+        ]
+      }
+    }
+  },
+  "configuration": {
+    "root_module": {}
+  }
+}`
+	var p tfjson.Plan
+	if err := json.Unmarshal([]byte(planJson), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	resources := types.ListResourcesFromPlan(&p)
+	if len(resources) != 2 {
+		t.Fatalf("expect 2 resources, but got %d", len(resources))
+	}
+	for _, r := range resources {
+		switch resource := r.(type) {
+		case *types.AzapiDataResource:
+			if resource.OldAddress(nil) != "data.azapi_resource.vnet" || resource.TargetProvider() != "azurerm" {
+				t.Fatalf("unexpected azapi data source: %s", resource.OldAddress(nil))
+			}
+			if resource.ApiVersion != "2023-04-01" {
+				t.Fatalf("expect api-version 2023-04-01, but got %s", resource.ApiVersion)
+			}
+		case *types.AzurermDataResource:
+			if resource.OldAddress(nil) != "data.azurerm_resource_group.test" || resource.TargetProvider() != "azapi" {
+				t.Fatalf("unexpected azurerm data source: %s", resource.OldAddress(nil))
+			}
+			if resource.NewAddress(nil) != "data.azapi_resource.resourceGroup_test" {
+				t.Fatalf("expect new address data.azapi_resource.resourceGroup_test, but got %s", resource.NewAddress(nil))
+			}
+		default:
+			t.Fatalf("unexpected resource %s", r.OldAddress(nil))
+		}
+	}
+}
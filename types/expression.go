@@ -0,0 +1,235 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// metaArguments are the arguments and blocks which are not migrated by the expressions
+var metaArguments = map[string]bool{
+	"count":       true,
+	"for_each":    true,
+	"depends_on":  true,
+	"provider":    true,
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+	"dynamic":     true,
+}
+
+// AppendExpressionReferences adds the expressions in the original `block` of resource `r` to its references,
+// so the migrated config keeps the expressions instead of the values they're evaluated to.
+func AppendExpressionReferences(r AzureResource, block *hclwrite.Block) {
+	switch resource := r.(type) {
+	case *AzapiResource:
+		if len(resource.Instances) != 0 {
+			refs := ListExpressionReferences(block, resource.Instances[0].Values, resource.IsMultipleResources())
+			mapReferencesToAzurerm(refs, resource.Instances[0].ResourceId)
+			resource.References = append(refs, resource.References...)
+		}
+	case *AzurermResource:
+		if len(resource.Instances) != 0 {
+			refs := ListExpressionReferences(block, resource.Instances[0].Values, resource.IsMultipleResources())
+			mapReferencesToAzapi(refs, resource.Instances[0].ResourceId)
+			resource.References = append(refs, resource.References...)
+		}
+	case *AzapiUpdateResource:
+		if resource.Change != nil {
+			refs := ListExpressionReferences(block, resource.Change.Before, false)
+			mapReferencesToAzurerm(refs, resource.Id)
+			resource.References = append(refs, resource.References...)
+		}
+	}
+}
+
+// ListExpressionReferences returns the expressions in resource `block` which are not literal values, e.g. `var.name`, `local.tags`,
+// `"${var.prefix}-kv"` or `length(var.zones)`. Objects, tuples and `jsonencode` are expanded, so the expressions in azapi `body` are
+// listed by their own paths. The name of the reference is the source of the expression, and the value is the value in the state `values`.
+// If `multiple` is true, the expressions using `count` or `each` are skipped, because their values are different among the instances.
+func ListExpressionReferences(block *hclwrite.Block, values interface{}, multiple bool) []Reference {
+	res := make([]Reference, 0)
+	if block == nil || block.Body() == nil {
+		return res
+	}
+	listBodyExpressions(block.Body(), values, "", multiple, &res)
+	return res
+}
+
+func listBodyExpressions(body *hclwrite.Body, values interface{}, prefix string, multiple bool, res *[]Reference) {
+	valueMap, _ := values.(map[string]interface{})
+	if valueMap == nil {
+		return
+	}
+	for name, attr := range body.Attributes() {
+		if metaArguments[name] {
+			continue
+		}
+		src := attr.Expr().BuildTokens(nil).Bytes()
+		expr, diags := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		listExpressions(expr, src, joinPath(prefix, name), valueMap[name], multiple, res)
+	}
+
+	indexes := make(map[string]int)
+	for _, block := range body.Blocks() {
+		if metaArguments[block.Type()] {
+			continue
+		}
+		index := indexes[block.Type()]
+		indexes[block.Type()]++
+		if list, ok := valueMap[block.Type()].([]interface{}); ok && index < len(list) {
+			listBodyExpressions(block.Body(), list[index], joinPath(prefix, fmt.Sprintf("%s.%d", block.Type(), index)), multiple, res)
+		}
+	}
+}
+
+func listExpressions(expr hclsyntax.Expression, src []byte, path string, value interface{}, multiple bool, res *[]Reference) {
+	if value == nil {
+		return
+	}
+	if _, diags := expr.Value(nil); !diags.HasErrors() {
+		// literal values are already in the generated config
+		return
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		if valueMap := toObject(value); valueMap != nil {
+			keys := make([]string, 0)
+			for _, item := range e.Items {
+				key, diags := item.KeyExpr.Value(nil)
+				if diags.HasErrors() || key.IsNull() || key.Type() != cty.String {
+					break
+				}
+				keys = append(keys, key.AsString())
+			}
+			if len(keys) == len(e.Items) {
+				for i, item := range e.Items {
+					listExpressions(item.ValueExpr, src, joinPath(path, keys[i]), valueMap[keys[i]], multiple, res)
+				}
+				return
+			}
+		}
+	case *hclsyntax.TupleConsExpr:
+		if list, ok := value.([]interface{}); ok && len(list) == len(e.Exprs) {
+			for i, element := range e.Exprs {
+				listExpressions(element, src, joinPath(path, fmt.Sprintf("%d", i)), list[i], multiple, res)
+			}
+			return
+		}
+	case *hclsyntax.FunctionCallExpr:
+		if e.Name == "jsonencode" && len(e.Args) == 1 {
+			if stringValue, ok := value.(string); ok {
+				var decoded interface{}
+				if err := json.Unmarshal([]byte(stringValue), &decoded); err == nil {
+					listExpressions(e.Args[0], src, path, decoded, multiple, res)
+					return
+				}
+			}
+		}
+	}
+	if multiple {
+		for _, traversal := range expr.Variables() {
+			if root := traversal.RootName(); root == "count" || root == "each" {
+				return
+			}
+		}
+	}
+	*res = append(*res, Reference{
+		Name:  strings.TrimSpace(string(expr.Range().SliceBytes(src))),
+		Value: value,
+		Path:  path,
+	})
+}
+
+// matchReference returns the name of the reference whose value is `value`, which is at `path` in the generated config.
+// The expressions mapped by the coverage report are only matched at the attributes they're mapped to. The other expressions are
+// matched by value, but a non-string value or an empty string is only matched if the attribute name matches the path of
+// the expression, because such values are likely shared by unrelated properties.
+func matchReference(refs []Reference, path string, value interface{}) (string, bool) {
+	for _, ref := range refs {
+		if ref.NewPath != "" && ref.NewPath == path && reflect.DeepEqual(ref.Value, value) {
+			return ref.Name, true
+		}
+	}
+	stringValue, isString := value.(string)
+	key := path[strings.LastIndex(path, ".")+1:]
+	for _, ref := range refs {
+		if ref.Path == "" || ref.NewPath != "" || !reflect.DeepEqual(ref.Value, value) {
+			continue
+		}
+		if (isString && stringValue != "") || keyMatchesPath(key, ref.Path) {
+			return ref.Name, true
+		}
+	}
+	if !isString {
+		return "", false
+	}
+	for _, ref := range refs {
+		if ref.Path != "" {
+			continue
+		}
+		if refValue, ok := ref.Value.(string); ok && refValue == stringValue {
+			return ref.Name, true
+		}
+	}
+	return "", false
+}
+
+// keyMatchesPath returns true if `key` is the name of the last property in `path`, e.g. `sku_name` matches `properties.sku.name`
+func keyMatchesPath(key string, path string) bool {
+	if key == "" {
+		return false
+	}
+	segments := strings.Split(path, ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if isIndex(segments[i]) {
+			continue
+		}
+		name := toSnakeCase(segments[i])
+		key = toSnakeCase(key)
+		return key == name || strings.HasSuffix(key, "_"+name)
+	}
+	return false
+}
+
+func isIndex(input string) bool {
+	if input == "" {
+		return false
+	}
+	for _, c := range input {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// toObject returns `value` as an object, the JSON string is decoded, e.g. the `body` of azapi provider v1
+func toObject(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case string:
+		var res map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &res); err == nil {
+			return res
+		}
+	}
+	return nil
+}
+
+func joinPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,132 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_InjectExpressionReferences(t *testing.T) {
+	original := `
+resource "azapi_resource" "test" {
+  type      = "Microsoft.Cache/redis@2023-08-01"
+  name      = "${var.prefix}-redis"
+  parent_id = azapi_resource.resourceGroup.id
+  location  = "westus"
+  body = jsonencode({
+    properties = {
+      sku = {
+        name     = var.sku
+        capacity = var.capacity
+      }
+      enableNonSslPort = false
+    }
+  })
+  tags = local.tags
+}
+`
+	values := map[string]interface{}{
+		"type":      "Microsoft.Cache/redis@2023-08-01",
+		"name":      "dev-redis",
+		"parent_id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg",
+		"location":  "westus",
+		"body":      `{"properties":{"sku":{"name":"Standard","capacity":2},"enableNonSslPort":false}}`,
+		"tags":      map[string]interface{}{"env": "dev"},
+	}
+	generated := `
+resource "azurerm_redis_cache" "test" {
+  name                = "dev-redis"
+  location            = "westus"
+  resource_group_name = "rg"
+  sku_name            = "Standard"
+  capacity            = 2
+  replicas_per_master = 2
+  tags = {
+    env = "dev"
+  }
+}
+`
+	originalFile, diags := hclwrite.ParseConfig([]byte(original), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	refs := types.ListExpressionReferences(originalFile.Body().Blocks()[0], values, false)
+	expectedRefs := map[string]string{
+		"name":                         `"${var.prefix}-redis"`,
+		"parent_id":                    "azapi_resource.resourceGroup.id",
+		"body.properties.sku.name":     "var.sku",
+		"body.properties.sku.capacity": "var.capacity",
+		"tags":                         "local.tags",
+	}
+	if len(refs) != len(expectedRefs) {
+		t.Fatalf("expect %d expressions, but got %v", len(expectedRefs), refs)
+	}
+	for _, ref := range refs {
+		if expectedRefs[ref.Path] != ref.Name {
+			t.Fatalf("expect expression %s at %s, but got %s", expectedRefs[ref.Path], ref.Path, ref.Name)
+		}
+	}
+
+	generatedFile, diags := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	block := types.InjectReference(generatedFile.Body().Blocks()[0], refs)
+	expected := map[string]string{
+		"name":                `"${var.prefix}-redis"`,
+		"location":            `"westus"`,
+		"sku_name":            "var.sku",
+		"capacity":            "var.capacity",
+		"replicas_per_master": "2",
+		"tags":                "local.tags",
+	}
+	for name, value := range expected {
+		attr := block.Body().GetAttribute(name)
+		if attr == nil {
+			t.Fatalf("expect attribute %s, but got nil", name)
+		}
+		if actual := strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes())); actual != value {
+			t.Fatalf("expect %s = %s, but got %s", name, value, actual)
+		}
+	}
+}
+
+func Test_InjectMappedReferences(t *testing.T) {
+	generated := `
+resource "azurerm_storage_account" "test" {
+  public_network_access = "Enabled"
+  sftp                  = "Enabled"
+}
+`
+	refs := []types.Reference{
+		{
+			Name:    "var.sftp",
+			Value:   "Enabled",
+			Path:    "body.properties.isSftpEnabled",
+			NewPath: "sftp",
+		},
+		{
+			Name:    "var.public_network_access",
+			Value:   "Enabled",
+			Path:    "body.properties.publicNetworkAccess",
+			NewPath: "public_network_access",
+		},
+	}
+	generatedFile, diags := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	block := types.InjectReference(generatedFile.Body().Blocks()[0], refs)
+	expected := map[string]string{
+		"public_network_access": "var.public_network_access",
+		"sftp":                  "var.sftp",
+	}
+	for name, value := range expected {
+		if actual := strings.TrimSpace(string(block.Body().GetAttribute(name).Expr().BuildTokens(nil).Bytes())); actual != value {
+			t.Fatalf("expect %s = %s, but got %s", name, value, actual)
+		}
+	}
+}
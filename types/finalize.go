@@ -0,0 +1,187 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// MarkerGenerated marks the state update blocks generated by migration, and MarkerOriginal followed by the resource address marks the
+// commented-out original blocks. They're removed by `aztfmigrate finalize` once the state reflects the migration.
+const (
+	MarkerGenerated = "aztfmigrate:generated"
+	MarkerOriginal  = "aztfmigrate:original"
+)
+
+// markerTokens returns the comment line of `marker`
+func markerTokens(marker string) hclwrite.Tokens {
+	return hclwrite.Tokens{
+		&hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte(fmt.Sprintf("# %s\n", marker)),
+		},
+	}
+}
+
+// appendMarkedBlock appends `block` to `body`, the state update blocks are preceded by the marker
+func appendMarkedBlock(body *hclwrite.Body, block *hclwrite.Block) {
+	if isStateBlock(block.Type()) {
+		body.AppendUnstructuredTokens(markerTokens(MarkerGenerated))
+	}
+	body.AppendBlock(block)
+}
+
+// jsonMarker returns the `//` property of `block` in JSON syntax, the state update blocks are marked
+func jsonMarker(block *hclwrite.Block) string {
+	if isStateBlock(block.Type()) {
+		return MarkerGenerated
+	}
+	return ""
+}
+
+// isStateUpdated returns true if the state update block, whose type is `blockType` and attributes are `attrs`, is reflected by the state.
+// `addresses` are the addresses of the resources in the state without the instance keys.
+func isStateUpdated(blockType string, attrs map[string]string, addresses map[string]bool) bool {
+	from := ResourceAddress(attrs["from"])
+	to := ResourceAddress(attrs["to"])
+	switch blockType {
+	case "moved":
+		return !addresses[from] && addresses[to]
+	case "import":
+		return addresses[to]
+	case "removed":
+		return !addresses[from]
+	}
+	return false
+}
+
+// FinalizeConfig removes the marked state update blocks which are reflected by the state, and the marked commented-out original blocks
+// which are removed from the state. `addresses` are the addresses of the resources in the state without the instance keys.
+// It returns the new content and the number of the removed blocks.
+func FinalizeConfig(src []byte, filename string, addresses map[string]bool) ([]byte, int, error) {
+	if helper.IsJsonConfig(filename) {
+		return finalizeJsonConfig(src, addresses)
+	}
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, 0, fmt.Errorf("parsing %s: %s", filename, diags.Error())
+	}
+	lines := strings.Split(string(src), "\n")
+	removed := make([]bool, len(lines))
+	count := 0
+
+	for _, block := range f.Body.(*hclsyntax.Body).Blocks {
+		// the line numbers start from 1, so the marker is at index `start - 2`
+		start, end := block.Range().Start.Line, block.Range().End.Line
+		if !isStateBlock(block.Type) || start < 2 || strings.TrimSpace(lines[start-2]) != "# "+MarkerGenerated {
+			continue
+		}
+		attrs := make(map[string]string)
+		for name, attr := range block.Body.Attributes {
+			attrs[name] = string(attr.Expr.Range().SliceBytes(src))
+		}
+		if !isStateUpdated(block.Type, attrs, addresses) {
+			continue
+		}
+		for i := start - 2; i < end; i++ {
+			removed[i] = true
+		}
+		count++
+	}
+
+	for i := 0; i < len(lines); i++ {
+		address, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "# "+MarkerOriginal+" ")
+		if !ok {
+			continue
+		}
+		// the commented-out block is the following comment lines
+		j := i + 1
+		for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "#") && !isMarkerLine(lines[j]) {
+			j++
+		}
+		if !addresses[ResourceAddress(address)] {
+			for k := i; k < j; k++ {
+				removed[k] = true
+			}
+			count++
+		}
+		i = j - 1
+	}
+
+	if count == 0 {
+		return src, 0, nil
+	}
+	res := make([]string, 0)
+	for i, line := range lines {
+		if removed[i] {
+			continue
+		}
+		// the blank lines around the removed blocks are collapsed
+		if strings.TrimSpace(line) == "" && i > 0 && removed[i-1] && (len(res) == 0 || strings.TrimSpace(res[len(res)-1]) == "") {
+			removed[i] = true
+			continue
+		}
+		res = append(res, line)
+	}
+	return hclwrite.Format([]byte(strings.Join(res, "\n"))), count, nil
+}
+
+func isMarkerLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "# "+MarkerGenerated || strings.HasPrefix(line, "# "+MarkerOriginal+" ")
+}
+
+// finalizeJsonConfig removes the state update blocks marked by the `//` property which are reflected by the state, the original blocks
+// are not kept in JSON config
+func finalizeJsonConfig(src []byte, addresses map[string]bool) ([]byte, int, error) {
+	root, err := helper.ParseJsonObject(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	count := 0
+	res := make(helper.JsonObject, 0)
+	for _, member := range root {
+		if !isStateBlock(member.Key) {
+			res = append(res, member)
+			continue
+		}
+		raw, _ := member.Value.(json.RawMessage)
+		var elements []json.RawMessage
+		if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+			if err := json.Unmarshal(raw, &elements); err != nil {
+				return nil, 0, err
+			}
+		} else {
+			elements = []json.RawMessage{raw}
+		}
+		kept := make([]interface{}, 0)
+		for _, element := range elements {
+			var body map[string]interface{}
+			if err := json.Unmarshal(element, &body); err != nil {
+				return nil, 0, err
+			}
+			attrs := make(map[string]string)
+			for _, name := range []string{"from", "to"} {
+				attrs[name], _ = body[name].(string)
+			}
+			if body["//"] == MarkerGenerated && isStateUpdated(member.Key, attrs, addresses) {
+				count++
+				continue
+			}
+			kept = append(kept, element)
+		}
+		if len(kept) != 0 {
+			res = append(res, helper.JsonMember{Key: member.Key, Value: kept})
+		}
+	}
+	if count == 0 {
+		return src, 0, nil
+	}
+	output, err := helper.FormatJson(res)
+	return output, count, err
+}
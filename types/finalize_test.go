@@ -0,0 +1,86 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_FinalizeConfig(t *testing.T) {
+	config := `resource "azapi_resource" "test" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "rg"
+}
+
+resource "azapi_resource" "other" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "rg2"
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fs := helper.OsFileSystem{}
+	for _, label := range []string{"test", "other"} {
+		removedBlock := hclwrite.NewBlock("removed", nil)
+		removedBlock.Body().SetAttributeRaw("from", helper.GetTokensForExpression("azapi_resource."+label))
+		importBlock := hclwrite.NewBlock("import", nil)
+		importBlock.Body().SetAttributeRaw("id", helper.GetTokensForExpression(`"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/`+label+`"`))
+		importBlock.Body().SetAttributeRaw("to", helper.GetTokensForExpression("azurerm_resource_group."+label))
+		newBlock := hclwrite.NewBlock("resource", []string{"azurerm_resource_group", label})
+		newBlock.Body().SetAttributeRaw("name", helper.GetTokensForExpression(`"`+label+`"`))
+		if err := types.ReplaceResourceBlock(fs, dir, types.Module{}, "azapi_resource."+label, []*hclwrite.Block{removedBlock, importBlock, newBlock}, types.ReplaceOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the migration of azapi_resource.other is not applied
+	addresses := map[string]bool{
+		"azurerm_resource_group.test": true,
+		"azapi_resource.other":        true,
+	}
+	actual, count, err := types.FinalizeConfig(src, "main.tf", addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("expect 3 blocks removed, but got %d", count)
+	}
+	expected := `resource "azurerm_resource_group" "test" {
+  name = "test"
+}
+
+# aztfmigrate:original azapi_resource.other
+# resource "azapi_resource" "other" {
+#   type = "Microsoft.Resources/resourceGroups@2024-03-01"
+#   name = "rg2"
+# }
+# 
+# aztfmigrate:generated
+removed {
+  from = azapi_resource.other
+}
+
+# aztfmigrate:generated
+import {
+  id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/other"
+  to = azurerm_resource_group.other
+}
+
+resource "azurerm_resource_group" "other" {
+  name = "other"
+}
+`
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
@@ -14,9 +14,10 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 		return resources
 	}
 
+	modules := getModules(p)
 	idMap := make(map[string]*tfjson.ResourceChange)
 	for _, resourceChange := range p.ResourceChanges {
-		if resourceChange == nil || resourceChange.Change == nil || resourceChange.ProviderName != "registry.terraform.io/hashicorp/azurerm" {
+		if resourceChange == nil || resourceChange.Change == nil || resourceChange.Mode == tfjson.DataResourceMode || resourceChange.ProviderName != "registry.terraform.io/hashicorp/azurerm" {
 			continue
 		}
 		idMap[getId(resourceChange.Change.Before)] = resourceChange
@@ -25,20 +26,30 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 	azapiResourceMap := make(map[string]*AzapiResource)
 	azapiUpdateResources := make([]AzapiUpdateResource, 0)
 	azurermResourceMap := make(map[string]*AzurermResource)
+	azapiActionResources := make([]*AzapiActionResource, 0)
+	azapiDataPlaneResources := make([]*AzapiDataPlaneResource, 0)
 	for _, resourceChange := range p.ResourceChanges {
-		if resourceChange == nil || resourceChange.Change == nil {
+		if resourceChange == nil || resourceChange.Change == nil || resourceChange.Mode == tfjson.DataResourceMode {
 			continue
 		}
 		if len(resourceChange.Change.Actions) != 0 && (resourceChange.Change.Actions[0] == tfjson.ActionCreate || resourceChange.Change.Actions[0] == tfjson.ActionDelete) {
 			log.Printf("[WARN] resource %s.%s's planned action is %v, which is not supported. Please apply the changes before running the migration tool", resourceChange.Type, resourceChange.Name, resourceChange.Change.Actions)
 			continue
 		}
+		module, ok := modules[resourceChange.ModuleAddress]
+		if !ok {
+			if strings.Contains(resourceChange.ModuleAddress, "[") {
+				log.Printf("[WARN] resource %s belongs to a module instance created by `count` or `for_each`, which is not supported", resourceChange.Address)
+			}
+			continue
+		}
 
 		switch resourceChange.Type {
 		case "azapi_resource":
-			address := fmt.Sprintf("%s.%s", resourceChange.Type, resourceChange.Name)
+			address := module.Qualify(fmt.Sprintf("%s.%s", resourceChange.Type, resourceChange.Name))
 			if azapiResourceMap[address] == nil {
 				azapiResourceMap[address] = &AzapiResource{
+					Module:       module,
 					Label:        resourceChange.Name,
 					ResourceType: "",
 					Instances:    make([]Instance, 0),
@@ -49,16 +60,18 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 				Index:      resourceChange.Index,
 				ResourceId: getId(resourceChange.Change.Before),
 				ApiVersion: getApiVersion(resourceChange.Change.Before),
+				Values:     resourceChange.Change.Before,
 			})
 
 		case "azapi_update_resource":
 			resourceId := getId(resourceChange.Change.Before)
-			if idMap[resourceId] == nil {
-				log.Printf("[WARN] resource azapi_update_resource.%s's target is not in the same terraform working directory", resourceChange.Name)
+			if idMap[resourceId] == nil || idMap[resourceId].ModuleAddress != resourceChange.ModuleAddress {
+				log.Printf("[WARN] resource %s's target is not in the same terraform module", module.Qualify("azapi_update_resource."+resourceChange.Name))
 				continue
 			}
 			rc := idMap[resourceId]
 			azapiUpdateResources = append(azapiUpdateResources, AzapiUpdateResource{
+				Module:       module,
 				OldLabel:     resourceChange.Name,
 				Label:        rc.Name,
 				Id:           resourceId,
@@ -66,15 +79,42 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 				ResourceType: rc.Type,
 				Change:       rc.Change,
 			})
+		case "azapi_resource_action":
+			if resourceChange.Index != nil {
+				log.Printf("[WARN] resource %s uses `count` or `for_each`, which is not supported", resourceChange.Address)
+				continue
+			}
+			azapiActionResources = append(azapiActionResources, &AzapiActionResource{
+				Module:     module,
+				Label:      resourceChange.Name,
+				ResourceId: getStringAttribute(resourceChange.Change.Before, "resource_id"),
+				ApiVersion: getApiVersion(resourceChange.Change.Before),
+				Action:     getStringAttribute(resourceChange.Change.Before, "action"),
+				Method:     getStringAttribute(resourceChange.Change.Before, "method"),
+			})
+
+		case "azapi_data_plane_resource":
+			if resourceChange.Index != nil {
+				log.Printf("[WARN] resource %s uses `count` or `for_each`, which is not supported", resourceChange.Address)
+				continue
+			}
+			before, _ := resourceChange.Change.Before.(map[string]interface{})
+			azapiDataPlaneResources = append(azapiDataPlaneResources, NewAzapiDataPlaneResource(module, resourceChange.Name,
+				getStringAttribute(before, "type"),
+				getStringAttribute(before, "parent_id"),
+				getStringAttribute(before, "name"),
+				before["output"]))
+
 		default:
 			if strings.HasPrefix(resourceChange.Type, "azurerm") {
-				address := fmt.Sprintf("%s.%s", resourceChange.Type, resourceChange.Name)
+				address := module.Qualify(fmt.Sprintf("%s.%s", resourceChange.Type, resourceChange.Name))
 				id := getId(resourceChange.Change.Before)
 				if azurermResourceMap[address] == nil {
 					if azureId, err := AzurermIdToAzureId(resourceChange.Type, id); err == nil {
 						id = azureId
 					}
 					azurermResourceMap[address] = &AzurermResource{
+						Module:          module,
 						OldResourceType: resourceChange.Type,
 						OldLabel:        resourceChange.Name,
 						NewResourceType: "azapi_resource",
@@ -86,6 +126,7 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 				azurermResourceMap[address].Instances = append(azurermResourceMap[address].Instances, Instance{
 					Index:      resourceChange.Index,
 					ResourceId: id,
+					Values:     resourceChange.Change.Before,
 				})
 			}
 		}
@@ -103,13 +144,13 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 	}
 
 	for index, resource := range azapiResources {
-		azapiResources[index].References = getReferencesForAddress(resource.OldAddress(nil), p, refValueMap)
-		azapiResources[index].InputProperties = getInputProperties(resource.OldAddress(nil), p)
+		azapiResources[index].References = getReferencesForAddress(resource.Module, resource.OldAddress(nil), p, refValueMap)
+		azapiResources[index].InputProperties = getInputProperties(resource.Module.Qualify(resource.OldAddress(nil)), p)
 	}
 	for i, resource := range azapiResources {
 		outputPropSet := make(map[string]bool)
 		for j, instance := range resource.Instances {
-			azapiResources[i].Instances[j].Outputs = getOutputsForAddress(resource.OldAddress(instance.Index), refValueMap)
+			azapiResources[i].Instances[j].Outputs = getOutputsForAddress(resource.Module, resource.OldAddress(instance.Index), refValueMap)
 			for _, output := range azapiResources[i].Instances[j].Outputs {
 				prop := strings.TrimPrefix(output.OldName, fmt.Sprintf("%s.output.", resource.OldAddress(instance.Index)))
 				if strings.HasPrefix(prop, "identity.userAssignedIdentities") {
@@ -125,11 +166,11 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 	}
 
 	for index, resource := range azapiUpdateResources {
-		azapiUpdateResources[index].References = getReferencesForAddress(resource.OldAddress(nil), p, refValueMap)
-		azapiUpdateResources[index].InputProperties = getInputProperties(resource.OldAddress(nil), p)
+		azapiUpdateResources[index].References = getReferencesForAddress(resource.Module, resource.OldAddress(nil), p, refValueMap)
+		azapiUpdateResources[index].InputProperties = getInputProperties(resource.Module.Qualify(resource.OldAddress(nil)), p)
 	}
 	for i, resource := range azapiUpdateResources {
-		azapiUpdateResources[i].outputs = getOutputsForAddress(resource.OldAddress(nil), refValueMap)
+		azapiUpdateResources[i].outputs = getOutputsForAddress(resource.Module, resource.OldAddress(nil), refValueMap)
 		azapiUpdateResources[i].OutputProperties = make([]string, 0)
 		for _, output := range azapiUpdateResources[i].outputs {
 			azapiUpdateResources[i].OutputProperties = append(azapiUpdateResources[i].OutputProperties, strings.TrimPrefix(output.OldName, fmt.Sprintf("%s.output.", resource.OldAddress(nil))))
@@ -137,7 +178,16 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 	}
 
 	for index, resource := range azurermResources {
-		azurermResources[index].References = getReferencesForAddress(resource.OldAddress(nil), p, refValueMap)
+		azurermResources[index].References = getReferencesForAddress(resource.Module, resource.OldAddress(nil), p, refValueMap)
+	}
+
+	for _, resource := range azapiActionResources {
+		resource.References = getReferencesForAddress(resource.Module, resource.OldAddress(nil), p, refValueMap)
+		resource.outputs = getOutputsForAddress(resource.Module, resource.OldAddress(nil), refValueMap)
+	}
+	for _, resource := range azapiDataPlaneResources {
+		resource.References = getReferencesForAddress(resource.Module, resource.OldAddress(nil), p, refValueMap)
+		resource.outputs = getOutputsForAddress(resource.Module, resource.OldAddress(nil), refValueMap)
 	}
 
 	for _, resource := range azapiResources {
@@ -149,6 +199,13 @@ func ListResourcesFromPlan(p *tfjson.Plan) []AzureResource {
 	for _, resource := range azurermResources {
 		resources = append(resources, &resource)
 	}
+	for _, resource := range azapiActionResources {
+		resources = append(resources, resource)
+	}
+	for _, resource := range azapiDataPlaneResources {
+		resources = append(resources, resource)
+	}
+	resources = append(resources, listDataResourcesFromState(p, modules, refValueMap)...)
 
 	return resources
 }
@@ -3,9 +3,9 @@ package types
 import (
 	"fmt"
 	"log"
-	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/Azure/aztfmigrate/helper"
@@ -14,22 +14,35 @@ import (
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
-// GetResourceBlock searches tf files in working directory and return `targetAddress` block
-func GetResourceBlock(workingDirectory, targetAddress string) (*hclwrite.Block, error) {
-	for _, file := range helper.ListHclFiles(workingDirectory) {
-		// #nosec G304
-		src, err := os.ReadFile(filepath.Join(workingDirectory, file.Name()))
+// configBlocks returns the top level blocks in config file `filename`, the blocks in JSON syntax are converted to native syntax
+func configBlocks(src []byte, filename string) ([]*hclwrite.Block, error) {
+	if helper.IsJsonConfig(filename) {
+		return jsonBlocks(src)
+	}
+	f, diag := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diag != nil && diag.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", filename, diag.Error())
+	}
+	if f == nil || f.Body() == nil {
+		return nil, nil
+	}
+	return f.Body().Blocks(), nil
+}
+
+// GetResourceBlock searches config files in working directory and return `targetAddress` block
+func GetResourceBlock(fs helper.FileSystem, workingDirectory, targetAddress string) (*hclwrite.Block, error) {
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
 		if err != nil {
 			return nil, err
 		}
-		f, diag := hclwrite.ParseConfig(src, file.Name(), hcl.InitialPos)
-		if f == nil || diag != nil && diag.HasErrors() || f.Body() == nil {
+		blocks, err := configBlocks(src, file)
+		if err != nil {
 			continue
 		}
-		for _, block := range f.Body().Blocks() {
-			if block != nil && block.Type() == "resource" {
-				address := strings.Join(block.Labels(), ".")
-				if targetAddress == address {
+		for _, block := range blocks {
+			if block != nil && blockAddress(block) != "" {
+				if targetAddress == blockAddress(block) {
 					return block, nil
 				}
 			}
@@ -38,100 +51,267 @@ func GetResourceBlock(workingDirectory, targetAddress string) (*hclwrite.Block,
 	return nil, nil
 }
 
-// ReplaceResourceBlock searches tf files in working directory and replace `targetAddress` block with `newBlock`
-func ReplaceResourceBlock(workingDirectory, targetAddress string, newBlocks []*hclwrite.Block) error {
-	for _, file := range helper.ListHclFiles(workingDirectory) {
-		// #nosec G304
-		src, err := os.ReadFile(filepath.Join(workingDirectory, file.Name()))
+// ListAttributeReferences returns the references to the attributes of resource `address` in config files in working directory as they're written,
+// e.g. `azurerm_key_vault.kv.vault_uri` and `azurerm_key_vault.kv[0].vault_uri` for `azurerm_key_vault.kv`
+func ListAttributeReferences(fs helper.FileSystem, workingDirectory string, address string) ([]string, error) {
+	refSet := make(map[string]bool)
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
 		if err != nil {
-			return err
+			return nil, err
 		}
-		f, diag := hclwrite.ParseConfig(src, file.Name(), hcl.InitialPos)
-		if f == nil || diag != nil && diag.HasErrors() || f.Body() == nil {
+		nodes, err := configNodes(src, file)
+		if err != nil {
 			continue
 		}
-		blocks := f.Body().Blocks()
-		f.Body().Clear()
-		found := false
-		for _, block := range blocks {
-			if block != nil && block.Type() == "resource" {
-				address := strings.Join(block.Labels(), ".")
-				if targetAddress == address {
-					f.Body().AppendUnstructuredTokens(CommentOutBlock(block))
-					f.Body().AppendNewline()
-					for _, newBlock := range newBlocks {
-						if newBlock == nil {
-							continue
-						}
-						f.Body().AppendBlock(newBlock)
-						f.Body().AppendNewline()
+		for _, node := range nodes {
+			_ = hclsyntax.VisitAll(node.node, func(n hclsyntax.Node) hcl.Diagnostics {
+				if expr, ok := n.(*hclsyntax.ScopeTraversalExpr); ok {
+					ref := string(expr.SrcRange.SliceBytes(node.src))
+					if strings.HasPrefix(ref, address+".") || strings.HasPrefix(ref, address+"[") {
+						refSet[ref] = true
 					}
-					found = true
-					continue
 				}
-			}
-			f.Body().AppendBlock(block)
-			f.Body().AppendNewline()
-		}
-		if found {
-			if err := os.WriteFile(filepath.Join(workingDirectory, file.Name()), hclwrite.Format(f.Bytes()), 0600); err != nil { // #nosec G703
-				log.Printf("[Error] saving configuration %s: %+v", file.Name(), err)
-			}
-			return nil
+				return nil
+			})
 		}
 	}
-	return nil
+	res := make([]string, 0)
+	for ref := range refSet {
+		res = append(res, ref)
+	}
+	sort.Strings(res)
+	return res, nil
 }
 
-// ReplaceGenericOutputs searches tf files in working directory and replace generic resource's output with new address
-func ReplaceGenericOutputs(workingDirectory string, outputs []Output) error {
-	for _, file := range helper.ListHclFiles(workingDirectory) {
-		// #nosec G304
-		src, err := os.ReadFile(filepath.Join(workingDirectory, file.Name()))
+// blockAddress returns the address of resource or data block, e.g. `azurerm_resource_group.test` or `data.azurerm_resource_group.test`
+func blockAddress(block *hclwrite.Block) string {
+	switch block.Type() {
+	case "resource":
+		return strings.Join(block.Labels(), ".")
+	case "data":
+		return "data." + strings.Join(block.Labels(), ".")
+	}
+	return ""
+}
+
+// OriginalComment, OriginalArchive and OriginalDelete are how the original block is handled after it's replaced
+const (
+	// OriginalComment comments out the original block in place
+	OriginalComment = "comment"
+	// OriginalArchive moves the original block to a file in the archive directory, one file per address
+	OriginalArchive = "archive"
+	// OriginalDelete removes the original block
+	OriginalDelete = "delete"
+)
+
+// ReplaceOptions configures how ReplaceResourceBlock handles the original block and where the new blocks are written
+type ReplaceOptions struct {
+	// Original is how the original block is handled, the allowed values are OriginalComment, OriginalArchive and OriginalDelete.
+	// OriginalComment is used if it's empty.
+	Original string
+	// ArchiveDirectory is the directory which the original blocks are archived to
+	ArchiveDirectory string
+	// Filename is the file in the working directory which the new blocks are appended to, they're written in place of the original
+	// block if it's empty
+	Filename string
+}
+
+// ArchivePath returns the file which the original block of resource `address` is archived to
+func ArchivePath(archiveDirectory string, address string) string {
+	return filepath.Join(archiveDirectory, address+".tf")
+}
+
+// ReplaceResourceBlock searches config files in working directory of `module` and replace `targetAddress` block with `newBlock`,
+// the new blocks are written in JSON syntax if the block is in a JSON config file. The commented-out original block and the state
+// update blocks are marked, so they can be removed by `aztfmigrate finalize`.
+func ReplaceResourceBlock(fs helper.FileSystem, workingDirectory string, module Module, targetAddress string, newBlocks []*hclwrite.Block, options ReplaceOptions) error {
+	inPlaceBlocks := newBlocks
+	if options.Filename != "" {
+		inPlaceBlocks = nil
+	}
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
 		if err != nil {
 			return err
 		}
-		f, diag := hclwrite.ParseConfig(src, file.Name(), hcl.InitialPos)
-		if f == nil || diag != nil && diag.HasErrors() || f.Body() == nil {
+		var res []byte
+		var original *hclwrite.Block
+		if helper.IsJsonConfig(file) {
+			res, original, err = replaceJsonResourceBlock(src, targetAddress, inPlaceBlocks)
+		} else {
+			res, original, err = replaceHclResourceBlock(src, file, targetAddress, module.Qualify(targetAddress), inPlaceBlocks, options.Original)
+		}
+		if err != nil {
+			log.Printf("[WARN] parsing %s: %+v", file, err)
 			continue
 		}
-		for _, block := range f.Body().Blocks() {
-			if block.Type() == "removed" || block.Type() == "import" || block.Type() == "moved" {
-				continue
-			}
-			if block != nil {
-				ReplaceOutputs(block, outputs)
+		if original == nil {
+			continue
+		}
+		if err := fs.WriteFile(filepath.Join(workingDirectory, file), res); err != nil {
+			log.Printf("[Error] saving configuration %s: %+v", file, err)
+		}
+		if options.Original == OriginalArchive {
+			archiveFile := hclwrite.NewEmptyFile()
+			archiveFile.Body().AppendBlock(original)
+			if err := fs.WriteFile(ArchivePath(options.ArchiveDirectory, module.Qualify(targetAddress)), hclwrite.Format(archiveFile.Bytes())); err != nil {
+				log.Printf("[Error] archiving %s: %+v", module.Qualify(targetAddress), err)
 			}
 		}
-		if err := os.WriteFile(filepath.Join(workingDirectory, file.Name()), hclwrite.Format(f.Bytes()), 0600); err != nil { // #nosec G703
-			log.Printf("[Error] saving configuration %s: %+v", file.Name(), err)
+		if options.Filename != "" {
+			return AppendBlocks(fs, workingDirectory, options.Filename, carryOverBlockComments(original, newBlocks))
 		}
+		return nil
 	}
 	return nil
 }
 
-func ReplaceOutputs(block *hclwrite.Block, outputs []Output) {
-	for attrName, attr := range block.Body().Attributes() {
-		attrValue := string(attr.Expr().BuildTokens(nil).Bytes())
-		for _, output := range outputs {
-			attrValue = strings.ReplaceAll(attrValue, output.OldName, output.NewName)
+// replaceHclResourceBlock replaces `targetAddress` block in native syntax config `src` with `newBlocks`, it returns the new content and
+// the original block, which is commented out in place if `original` is OriginalComment or empty. `qualifiedAddress` is the absolute
+// address of the block, which is recorded in the marker. It returns nil if the block is not found.
+// Only the lines of the original block are replaced, so the rest of the file, including the comments, is kept as it is.
+func replaceHclResourceBlock(src []byte, filename string, targetAddress string, qualifiedAddress string, newBlocks []*hclwrite.Block, original string) ([]byte, *hclwrite.Block, error) {
+	f, diag := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diag != nil && diag.HasErrors() {
+		return nil, nil, fmt.Errorf("parsing %s: %s", filename, diag.Error())
+	}
+	syntaxFile, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("parsing %s: %s", filename, diags.Error())
+	}
+	if f == nil || f.Body() == nil {
+		return nil, nil, nil
+	}
+	// the blocks parsed by hclwrite and hclsyntax are in the same order
+	blocks := f.Body().Blocks()
+	syntaxBlocks := syntaxFile.Body.(*hclsyntax.Body).Blocks
+	index := -1
+	for i, block := range blocks {
+		if i < len(syntaxBlocks) && blockAddress(block) != "" && targetAddress == blockAddress(block) {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, nil, nil
+	}
+	found := blocks[index]
+	syntaxBlock := syntaxBlocks[index]
+
+	replacement := hclwrite.NewEmptyFile()
+	if original == "" || original == OriginalComment {
+		// the lead comments are carried over to the migrated block, so they're not commented out
+		replacement.Body().AppendUnstructuredTokens(markerTokens(MarkerOriginal + " " + qualifiedAddress))
+		if block := parseBlock(append(syntaxBlock.Range().SliceBytes(src), '\n')); block != nil {
+			replacement.Body().AppendUnstructuredTokens(CommentOutBlock(block))
+		} else {
+			replacement.Body().AppendUnstructuredTokens(CommentOutBlock(found))
 		}
-		block.Body().SetAttributeRaw(attrName, helper.GetTokensForExpression(attrValue))
+		replacement.Body().AppendNewline()
+	}
+	for _, newBlock := range carryOverBlockComments(found, newBlocks) {
+		appendMarkedBlock(replacement.Body(), newBlock)
+		replacement.Body().AppendNewline()
 	}
-	for index := range block.Body().Blocks() {
-		ReplaceOutputs(block.Body().Blocks()[index], outputs)
+
+	// the line numbers start from 1, the lines of the lead comments and the block are replaced
+	lines := strings.Split(string(src), "\n")
+	start := syntaxBlock.Range().Start.Line - 1 - len(leadComments(found.BuildTokens(nil)))
+	end := syntaxBlock.Range().End.Line
+	res := append([]string{}, lines[:start]...)
+	if content := strings.TrimRight(string(replacement.Bytes()), "\n"); content != "" {
+		res = append(res, strings.Split(content, "\n")...)
+	} else if end < len(lines) && strings.TrimSpace(lines[end]) == "" && (len(res) == 0 || strings.TrimSpace(res[len(res)-1]) == "") {
+		// the blank line after the removed block is collapsed
+		end++
+	}
+	res = append(res, lines[end:]...)
+	return hclwrite.Format([]byte(strings.Join(res, "\n"))), found, nil
+}
+
+// parseBlock parses the source of a single block in native syntax, it returns nil if it's invalid
+func parseBlock(src []byte) *hclwrite.Block {
+	f, diag := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diag.HasErrors() || f == nil || len(f.Body().Blocks()) != 1 {
+		return nil
 	}
+	return f.Body().Blocks()[0]
 }
 
-// UpdateMigratedResourceBlock searches tf files in working directory and update generic patch resource's target
-func UpdateMigratedResourceBlock(workingDirectory string, resources []AzapiUpdateResource) error {
-	for _, file := range helper.ListHclFiles(workingDirectory) {
-		// #nosec G304
-		src, err := os.ReadFile(filepath.Join(workingDirectory, file.Name()))
+// AppendBlocks appends `blocks` to file `filename` in working directory, the file will be created if it doesn't exist.
+// The blocks are written in JSON syntax if `filename` is a JSON config file.
+func AppendBlocks(fs helper.FileSystem, workingDirectory, filename string, blocks []*hclwrite.Block) error {
+	filePath := filepath.Join(workingDirectory, filename)
+	if helper.IsJsonConfig(filename) {
+		return appendJsonBlocks(fs, filePath, blocks)
+	}
+	f := hclwrite.NewEmptyFile()
+	if src, err := fs.ReadFile(filePath); err == nil {
+		var diag hcl.Diagnostics
+		f, diag = hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+		if diag != nil && diag.HasErrors() {
+			return fmt.Errorf("parsing %s: %s", filename, diag.Error())
+		}
+	}
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		f.Body().AppendNewline()
+		appendMarkedBlock(f.Body(), block)
+	}
+	return fs.WriteFile(filePath, hclwrite.Format(f.Bytes()))
+}
+
+// ReplaceGenericOutputs searches config files in working directory and replace generic resource's output with new address,
+// the files in JSON syntax are included
+func ReplaceGenericOutputs(fs helper.FileSystem, workingDirectory string, outputs []Output) error {
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
 		if err != nil {
 			return err
 		}
-		f, diag := hclwrite.ParseConfig(src, file.Name(), hcl.InitialPos)
+		res, err := ReplaceReferences(src, file, outputs)
+		if err != nil {
+			log.Printf("[WARN] replacing references in %s: %+v", file, err)
+			continue
+		}
+		if string(res) == string(src) {
+			continue
+		}
+		if !helper.IsJsonConfig(file) {
+			res = hclwrite.Format(res)
+		}
+		if err := fs.WriteFile(filepath.Join(workingDirectory, file), res); err != nil {
+			log.Printf("[Error] saving configuration %s: %+v", file, err)
+		}
+	}
+	return nil
+}
+
+// UpdateMigratedResourceBlock searches config files in working directory and update generic patch resource's target
+func UpdateMigratedResourceBlock(fs helper.FileSystem, workingDirectory string, resources []AzapiUpdateResource) error {
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
+		if err != nil {
+			return err
+		}
+		if helper.IsJsonConfig(file) {
+			res, err := updateJsonResourceBlocks(src, resources)
+			if err != nil {
+				log.Printf("[WARN] parsing %s: %+v", file, err)
+				continue
+			}
+			if res == nil {
+				continue
+			}
+			if err := fs.WriteFile(filepath.Join(workingDirectory, file), res); err != nil {
+				log.Printf("[Error] saving configuration %s: %+v", file, err)
+			}
+			continue
+		}
+		f, diag := hclwrite.ParseConfig(src, file, hcl.InitialPos)
 		if f == nil || diag != nil && diag.HasErrors() || f.Body() == nil {
 			continue
 		}
@@ -147,8 +327,8 @@ func UpdateMigratedResourceBlock(workingDirectory string, resources []AzapiUpdat
 			}
 		}
 
-		if err := os.WriteFile(filepath.Join(workingDirectory, file.Name()), hclwrite.Format(f.Bytes()), 0600); err != nil { // #nosec G703
-			log.Printf("[Error] saving configuration %s: %+v", file.Name(), err)
+		if err := fs.WriteFile(filepath.Join(workingDirectory, file), hclwrite.Format(f.Bytes())); err != nil {
+			log.Printf("[Error] saving configuration %s: %+v", file, err)
 		}
 	}
 	return nil
@@ -259,28 +439,28 @@ func recursiveUpdate(old *hclwrite.Block, new *hclwrite.Block, before interface{
 
 // InjectReference replaces `block`'s literal value with reference provided by `refs`
 func InjectReference(block *hclwrite.Block, refs []Reference) *hclwrite.Block {
+	injectReference(block, "", refs)
+	return block
+}
+
+// injectReference replaces the literal values in `block` whose path is `prefix`, the paths of the nested blocks don't contain indexes
+func injectReference(block *hclwrite.Block, prefix string, refs []Reference) {
 	if block.Body() == nil {
-		return block
+		return
 	}
-	search := make([]string, 0)
-	replacement := make([]string, 0)
-	for _, ref := range refs {
-		if stringValue, ok := ref.Value.(string); ok {
-			search = append(search, stringValue)
-			replacement = append(replacement, ref.Name)
-		}
+	replace := func(path string, value interface{}) (string, bool) {
+		return matchReference(refs, path, value)
 	}
 	for attrName, attr := range block.Body().Attributes() {
 		if input := helper.GetValueFromExpression(attr.Expr().BuildTokens(nil)); input != nil {
-			if output, found := helper.ToHclSearchReplace(input, search, replacement); found {
+			if output, found := helper.ToHclReplaceFunc(input, joinPath(prefix, attrName), replace); found {
 				block.Body().SetAttributeRaw(attrName, helper.GetTokensForExpression(output))
 			}
 		}
 	}
-	for index := range block.Body().Blocks() {
-		InjectReference(block.Body().Blocks()[index], refs)
+	for _, nestedBlock := range block.Body().Blocks() {
+		injectReference(nestedBlock, joinPath(prefix, nestedBlock.Type()), refs)
 	}
-	return block
 }
 
 // GetValuePropMap returns a map from literal value to reference
@@ -0,0 +1,170 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/Azure/aztfmigrate/azurerm/schema"
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	azapiSchema "github.com/magodo/tfadd/providers/azapi"
+	tfpluginschema "github.com/magodo/tfpluginschema/schema"
+)
+
+// metaBlockSchemas are the schemas of the meta-argument blocks which can be nested in any resource block
+var metaBlockSchemas = map[string]*helper.JsonBlockSchema{
+	"lifecycle": {},
+	"provisioner": {
+		Labels: 1,
+		Blocks: map[string]*helper.JsonBlockSchema{"connection": {}},
+	},
+	"connection": {},
+	"timeouts":   {},
+}
+
+// jsonSchema returns the schema of top level block `blockType` with `labels` in JSON syntax, the nested blocks of the resources are from the provider schemas
+func jsonSchema(blockType string, labels []string) *helper.JsonBlockSchema {
+	switch blockType {
+	case "removed":
+		return &helper.JsonBlockSchema{Blocks: map[string]*helper.JsonBlockSchema{"lifecycle": {}}}
+	case "resource", "data":
+	default:
+		return nil
+	}
+	res := &helper.JsonBlockSchema{Blocks: make(map[string]*helper.JsonBlockSchema)}
+	if len(labels) != 0 {
+		if sch := schema.ResourceSchema(labels[0]); sch != nil && sch.Block != nil {
+			res = azurermJsonSchema(sch.Block)
+		} else if sch := azapiSchema.ProviderSchemaInfo.ResourceSchemas[labels[0]]; sch != nil && sch.Block != nil {
+			res = azapiJsonSchema(sch.Block)
+		}
+	}
+	for name, sch := range metaBlockSchemas {
+		res.Blocks[name] = sch
+	}
+	return res
+}
+
+func azurermJsonSchema(block *schema.SchemaBlock) *helper.JsonBlockSchema {
+	res := &helper.JsonBlockSchema{Blocks: make(map[string]*helper.JsonBlockSchema)}
+	for name, nestedBlock := range block.NestedBlocks {
+		if nestedBlock.Block == nil {
+			res.Blocks[name] = &helper.JsonBlockSchema{}
+			continue
+		}
+		res.Blocks[name] = azurermJsonSchema(nestedBlock.Block)
+	}
+	return res
+}
+
+func azapiJsonSchema(block *tfpluginschema.SchemaBlock) *helper.JsonBlockSchema {
+	res := &helper.JsonBlockSchema{Blocks: make(map[string]*helper.JsonBlockSchema)}
+	for _, nestedBlock := range block.BlockTypes {
+		if nestedBlock.Block == nil {
+			res.Blocks[nestedBlock.TypeName] = &helper.JsonBlockSchema{}
+			continue
+		}
+		res.Blocks[nestedBlock.TypeName] = azapiJsonSchema(nestedBlock.Block)
+	}
+	return res
+}
+
+// jsonBlocks returns the top level blocks in JSON config `src` in native syntax
+func jsonBlocks(src []byte) ([]*hclwrite.Block, error) {
+	return helper.JsonBlocks(src, jsonSchema)
+}
+
+// replaceJsonResourceBlock removes `targetAddress` block from JSON config `src` and appends `newBlocks` in JSON syntax, it returns the
+// new content and the original block in native syntax. JSON has no comments, so the original block is removed instead of commented out.
+// It returns nil if the block is not found.
+func replaceJsonResourceBlock(src []byte, targetAddress string, newBlocks []*hclwrite.Block) ([]byte, *hclwrite.Block, error) {
+	root, err := helper.ParseJsonObject(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks, err := jsonBlocks(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, block := range blocks {
+		if blockAddress(block) != targetAddress {
+			continue
+		}
+		root, _, err = helper.UpdateJsonBlock(root, block.Type(), block.Labels(), func(json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, newBlock := range newBlocks {
+			if newBlock == nil {
+				continue
+			}
+			if root, err = helper.AppendJsonBlock(root, newBlock, jsonMarker(newBlock)); err != nil {
+				return nil, nil, err
+			}
+		}
+		res, err := helper.FormatJson(root)
+		return res, block, err
+	}
+	return nil, nil, nil
+}
+
+// updateJsonResourceBlocks updates the generic patch resources' targets in JSON config `src`, it returns nil if no resource is updated
+func updateJsonResourceBlocks(src []byte, resources []AzapiUpdateResource) ([]byte, error) {
+	root, err := helper.ParseJsonObject(src)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := jsonBlocks(src)
+	if err != nil {
+		return nil, err
+	}
+	updated := false
+	for _, block := range blocks {
+		if block.Type() != "resource" {
+			continue
+		}
+		for _, r := range resources {
+			if r.NewAddress(nil) != blockAddress(block) {
+				continue
+			}
+			recursiveUpdate(block, r.Block, r.Change.Before, r.Change.After)
+			root, _, err = helper.UpdateJsonBlock(root, block.Type(), block.Labels(), func(json.RawMessage) (interface{}, error) {
+				return helper.BlockToJson(block)
+			})
+			if err != nil {
+				return nil, err
+			}
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		return nil, nil
+	}
+	return helper.FormatJson(root)
+}
+
+// appendJsonBlocks appends `blocks` to JSON config file `filePath`, the file will be created if it doesn't exist
+func appendJsonBlocks(fs helper.FileSystem, filePath string, blocks []*hclwrite.Block) error {
+	root := make(helper.JsonObject, 0)
+	if src, err := fs.ReadFile(filePath); err == nil {
+		if root, err = helper.ParseJsonObject(src); err != nil {
+			return err
+		}
+	}
+	var err error
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		if root, err = helper.AppendJsonBlock(root, block, jsonMarker(block)); err != nil {
+			return err
+		}
+	}
+	res, err := helper.FormatJson(root)
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(filePath, res)
+}
@@ -0,0 +1,159 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_ReplaceResourceBlockJson(t *testing.T) {
+	config := `{
+  "resource": {
+    "azurerm_resource_group": {
+      "test": {
+        "name": "${var.name}",
+        "location": "westeurope",
+        "tags": {
+          "env": "test"
+        },
+        "lifecycle": {
+          "ignore_changes": ["tags"]
+        }
+      }
+    },
+    "azurerm_virtual_network": {
+      "test": {
+        "name": "vnet",
+        "resource_group_name": "${azurerm_resource_group.test.name}"
+      }
+    }
+  }
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fs := helper.OsFileSystem{}
+	block, err := types.GetResourceBlock(fs, dir, "azurerm_resource_group.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil {
+		t.Fatal("expect the resource block in JSON config, but got nil")
+	}
+	expectedBlock := `resource "azurerm_resource_group" "test" {
+  name     = var.name
+  location = "westeurope"
+  tags = {
+    env = "test"
+  }
+  lifecycle {
+    ignore_changes = [tags]
+  }
+}
+`
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(block)
+	if actual := string(hclwrite.Format(f.Bytes())); actual != expectedBlock {
+		t.Fatalf("expect block:\n%s\nbut got:\n%s", expectedBlock, actual)
+	}
+
+	movedBlock := hclwrite.NewBlock("moved", nil)
+	movedBlock.Body().SetAttributeRaw("from", helper.GetTokensForExpression("azurerm_resource_group.test"))
+	movedBlock.Body().SetAttributeRaw("to", helper.GetTokensForExpression("azapi_resource.test"))
+	newBlock := hclwrite.NewBlock("resource", []string{"azapi_resource", "test"})
+	newBlock.Body().SetAttributeRaw("type", helper.GetTokensForExpression(`"Microsoft.Resources/resourceGroups@2024-03-01"`))
+	newBlock.Body().SetAttributeRaw("name", helper.GetTokensForExpression("var.name"))
+	newBlock.Body().SetAttributeRaw("body", helper.GetTokensForExpression(`{
+  properties = {}
+}`))
+	if err := types.ReplaceResourceBlock(fs, dir, types.Module{}, "azurerm_resource_group.test", []*hclwrite.Block{movedBlock, newBlock}, types.ReplaceOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "resource": {
+    "azurerm_virtual_network": {
+      "test": {
+        "name": "vnet",
+        "resource_group_name": "${azurerm_resource_group.test.name}"
+      }
+    },
+    "azapi_resource": {
+      "test": {
+        "type": "Microsoft.Resources/resourceGroups@2024-03-01",
+        "name": "${var.name}",
+        "body": {
+          "properties": {}
+        }
+      }
+    }
+  },
+  "moved": [
+    {
+      "//": "aztfmigrate:generated",
+      "from": "azurerm_resource_group.test",
+      "to": "azapi_resource.test"
+    }
+  ]
+}
+`
+	actual, err := os.ReadFile(filepath.Join(dir, "main.tf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func Test_AppendBlocksJson(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "migrations.tf.json"), []byte(`{"moved": {"from": "module.a.x.a", "to": "module.a.y.a"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	removedBlock := hclwrite.NewBlock("removed", nil)
+	removedBlock.Body().SetAttributeRaw("from", helper.GetTokensForExpression("module.a.azurerm_resource_group.test"))
+	lifecycleBlock := removedBlock.Body().AppendNewBlock("lifecycle", nil)
+	lifecycleBlock.Body().SetAttributeRaw("destroy", helper.GetTokensForExpression("false"))
+	movedBlock := hclwrite.NewBlock("moved", nil)
+	movedBlock.Body().SetAttributeRaw("from", helper.GetTokensForExpression("module.a.x.b"))
+	movedBlock.Body().SetAttributeRaw("to", helper.GetTokensForExpression("module.a.y.b"))
+	if err := types.AppendBlocks(helper.OsFileSystem{}, dir, "migrations.tf.json", []*hclwrite.Block{removedBlock, movedBlock}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "moved": [
+    {
+      "from": "module.a.x.a",
+      "to": "module.a.y.a"
+    },
+    {
+      "//": "aztfmigrate:generated",
+      "from": "module.a.x.b",
+      "to": "module.a.y.b"
+    }
+  ],
+  "removed": [
+    {
+      "//": "aztfmigrate:generated",
+      "from": "module.a.azurerm_resource_group.test",
+      "lifecycle": {
+        "destroy": false
+      }
+    }
+  ]
+}
+`
+	actual, err := os.ReadFile(filepath.Join(dir, "migrations.tf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
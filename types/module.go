@@ -0,0 +1,120 @@
+package types
+
+import (
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Module describes the module which a resource belongs to
+type Module struct {
+	// Address is the module address without instance keys, e.g. `module.network.module.subnet`. It's empty for the root module.
+	Address string
+	// Dir is the directory of the module's configuration, relative to the root module
+	Dir string
+}
+
+func (m Module) IsRoot() bool {
+	return m.Address == ""
+}
+
+// Qualify returns the absolute address of `address` which is relative to the module
+func (m Module) Qualify(address string) string {
+	if m.IsRoot() {
+		return address
+	}
+	return m.Address + "." + address
+}
+
+// Traversal returns the absolute traversal of resource `resourceType`.`label` in the module, it's used in the state update blocks in the root module
+func (m Module) Traversal(resourceType string, label string) hcl.Traversal {
+	names := make([]string, 0)
+	if !m.IsRoot() {
+		names = append(names, strings.Split(m.Address, ".")...)
+	}
+	names = append(names, resourceType, label)
+	res := hcl.Traversal{hcl.TraverseRoot{Name: names[0]}}
+	for _, name := range names[1:] {
+		res = append(res, hcl.TraverseAttr{Name: name})
+	}
+	return res
+}
+
+// getModules returns all local modules called by the configuration, the key is the module address
+func getModules(p *tfjson.Plan) map[string]Module {
+	res := map[string]Module{
+		"": {},
+	}
+	if p.Config == nil || p.Config.RootModule == nil {
+		return res
+	}
+	collectModules(p.Config.RootModule, Module{}, res)
+
+	// the config of a directory shared by multiple module calls can't be migrated for one call without changing the others
+	dirAddresses := make(map[string][]string)
+	for address, module := range res {
+		dirAddresses[path.Clean(module.Dir)] = append(dirAddresses[path.Clean(module.Dir)], address)
+	}
+	for dir, addresses := range dirAddresses {
+		if len(addresses) < 2 {
+			continue
+		}
+		sort.Strings(addresses)
+		log.Printf("[WARN] directory %q is shared by %s, resources in it will be skipped", dir, strings.Join(addresses, ", "))
+		for _, address := range addresses {
+			if address != "" {
+				delete(res, address)
+			}
+		}
+	}
+	return res
+}
+
+func collectModules(configModule *tfjson.ConfigModule, parent Module, res map[string]Module) {
+	if configModule == nil {
+		return
+	}
+	for name, call := range configModule.ModuleCalls {
+		if call == nil {
+			continue
+		}
+		address := parent.Qualify("module." + name)
+		if !isLocalModuleSource(call.Source) {
+			log.Printf("[WARN] %s's source %q is not a local path, resources in it will be skipped", address, call.Source)
+			continue
+		}
+		module := Module{
+			Address: address,
+			Dir:     path.Join(parent.Dir, call.Source),
+		}
+		res[address] = module
+		collectModules(call.Module, module, res)
+	}
+}
+
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// findConfigModule returns the configuration of module `moduleAddress`
+func findConfigModule(p *tfjson.Plan, moduleAddress string) *tfjson.ConfigModule {
+	if p.Config == nil {
+		return nil
+	}
+	configModule := p.Config.RootModule
+	if moduleAddress == "" {
+		return configModule
+	}
+	parts := strings.Split(moduleAddress, ".")
+	for i := 1; i < len(parts); i += 2 {
+		if configModule == nil || configModule.ModuleCalls[parts[i]] == nil {
+			return nil
+		}
+		configModule = configModule.ModuleCalls[parts[i]].Module
+	}
+	return configModule
+}
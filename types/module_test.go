@@ -0,0 +1,199 @@
+package types_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func Test_ListResourcesFromPlanInModule(t *testing.T) {
+	planJson := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "module.network.azapi_resource.vnet",
+      "module_address": "module.network",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "vnet",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+          "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+        }
+      }
+    },
+    {
+      "address": "module.remote.azapi_resource.vnet",
+      "module_address": "module.remote",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "vnet",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet2",
+          "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+        }
+      }
+    }
+  ],
+  "configuration": {
+    "root_module": {
+      "module_calls": {
+        "network": {
+          "source": "./modules/network",
+          "module": {
+            "resources": [
+              {
+                "address": "azapi_resource.vnet",
+                "mode": "managed",
+                "type": "azapi_resource",
+                "name": "vnet"
+              }
+            ]
+          }
+        },
+        "remote": {
+          "source": "Azure/avm-res-network-virtualnetwork/azurerm",
+          "module": {}
+        }
+      }
+    }
+  }
+}`
+	var p tfjson.Plan
+	if err := json.Unmarshal([]byte(planJson), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	resources := types.ListResourcesFromPlan(&p)
+	if len(resources) != 1 {
+		t.Fatalf("expect 1 resource, but got %d", len(resources))
+	}
+	resource := resources[0]
+	if resource.OldAddress(nil) != "azapi_resource.vnet" {
+		t.Errorf("expect address azapi_resource.vnet, but got %s", resource.OldAddress(nil))
+	}
+	module := resource.GetModule()
+	if module.Address != "module.network" || module.Dir != "modules/network" {
+		t.Errorf("expect module.network in modules/network, but got %#v", module)
+	}
+	if actual := module.Qualify(resource.OldAddress(nil)); actual != "module.network.azapi_resource.vnet" {
+		t.Errorf("expect qualified address module.network.azapi_resource.vnet, but got %s", actual)
+	}
+}
+
+func Test_ModuleTraversal(t *testing.T) {
+	testcases := []struct {
+		Module types.Module
+		Expect string
+	}{
+		{
+			Module: types.Module{},
+			Expect: "azapi_resource.test",
+		},
+		{
+			Module: types.Module{Address: "module.a.module.b", Dir: "modules/a/b"},
+			Expect: "module.a.module.b.azapi_resource.test",
+		},
+	}
+	for _, testcase := range testcases {
+		block := hclwrite.NewBlock("removed", nil)
+		block.Body().SetAttributeTraversal("from", testcase.Module.Traversal("azapi_resource", "test"))
+		if actual := strings.TrimSpace(string(block.Body().GetAttribute("from").Expr().BuildTokens(nil).Bytes())); actual != testcase.Expect {
+			t.Errorf("expect %q but got %q", testcase.Expect, actual)
+		}
+	}
+}
+
+func Test_ListResourcesFromPlanInSharedModule(t *testing.T) {
+	planJson := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "module.a.azapi_resource.vnet",
+      "module_address": "module.a",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "vnet",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/a",
+          "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+        }
+      }
+    },
+    {
+      "address": "module.b.azapi_resource.vnet",
+      "module_address": "module.b",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "vnet",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/b",
+          "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+        }
+      }
+    },
+    {
+      "address": "module.c.azapi_resource.vnet",
+      "module_address": "module.c",
+      "mode": "managed",
+      "type": "azapi_resource",
+      "name": "vnet",
+      "provider_name": "registry.terraform.io/azure/azapi",
+      "change": {
+        "actions": ["no-op"],
+        "before": {
+          "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/c",
+          "type": "Microsoft.Network/virtualNetworks@2023-04-01"
+        }
+      }
+    }
+  ],
+  "configuration": {
+    "root_module": {
+      "module_calls": {
+        "a": {
+          "source": "./modules/x",
+          "module": {}
+        },
+        "b": {
+          "source": "./modules/x",
+          "module": {}
+        },
+        "c": {
+          "source": "./modules/y",
+          "module": {}
+        }
+      }
+    }
+  }
+}`
+	var p tfjson.Plan
+	if err := json.Unmarshal([]byte(planJson), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	// module.a and module.b share ./modules/x, migrating one of them would change the other
+	resources := types.ListResourcesFromPlan(&p)
+	if len(resources) != 1 {
+		t.Fatalf("expect 1 resource, but got %d", len(resources))
+	}
+	if actual := resources[0].GetModule().Qualify(resources[0].OldAddress(nil)); actual != "module.c.azapi_resource.vnet" {
+		t.Errorf("expect module.c.azapi_resource.vnet, but got %s", actual)
+	}
+}
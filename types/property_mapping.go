@@ -0,0 +1,113 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfmigrate/azurerm/coverage"
+	"github.com/Azure/aztfmigrate/azurerm/schema"
+)
+
+// topLevelProperties are the properties which have the same path in the API and in both providers
+var topLevelProperties = map[string]bool{
+	"name":     true,
+	"location": true,
+	"tags":     true,
+}
+
+// mapReferencesToAzurerm sets the azurerm attribute paths of the expressions in azapi resource `id`, the expressions in `body` are
+// mapped by the coverage report, so they're injected into the exact attribute instead of the attribute holding the same value.
+func mapReferencesToAzurerm(refs []Reference, id string) {
+	idPattern, _ := GetIdPattern(id)
+	for i, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		if topLevelProperties[ref.Path] {
+			refs[i].NewPath = ref.Path
+			continue
+		}
+		if prop, ok := strings.CutPrefix(ref.Path, "body."); ok && !hasIndex(prop) {
+			refs[i].NewPath = coverage.GetAttributePath(prop, idPattern)
+		}
+	}
+}
+
+// mapReferencesToAzapi sets the azapi paths of the expressions in azurerm resource `id`, it's the reverse of mapReferencesToAzurerm
+func mapReferencesToAzapi(refs []Reference, id string) {
+	idPattern, _ := GetIdPattern(id)
+	for i, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		if topLevelProperties[ref.Path] {
+			refs[i].NewPath = ref.Path
+			continue
+		}
+		if prop := coverage.GetPropertyPath(removeIndexOfProp(ref.Path), idPattern); prop != "" {
+			refs[i].NewPath = "body." + prop
+		}
+	}
+}
+
+// mappedOutputName returns the reference to the azurerm attribute which output `oldName` of azapi resource `oldAddress` maps to
+// in the coverage report, e.g. `azurerm_redis_cache.test.hostname` for `azapi_resource.test.output.properties.hostName`.
+// It returns empty string if the output isn't mapped.
+func mappedOutputName(oldName string, oldAddress string, newAddress string, resourceType string, id string) string {
+	prop, ok := strings.CutPrefix(oldName, oldAddress+".output.")
+	if !ok || hasIndex(prop) {
+		return ""
+	}
+	idPattern, _ := GetIdPattern(id)
+	attributePath := coverage.GetAttributePath(prop, idPattern)
+	if attributePath == "" {
+		return ""
+	}
+	return attributeReference(newAddress, resourceType, attributePath)
+}
+
+// attributeReference returns the reference to attribute `attributePath` of azurerm resource `address`, the nested list blocks are
+// referenced by their first element. It returns empty string if the attribute isn't found in the schema or can't be referenced.
+func attributeReference(address string, resourceType string, attributePath string) string {
+	sch := schema.ResourceSchema(resourceType)
+	if sch == nil || sch.Block == nil {
+		return ""
+	}
+	block := sch.Block
+	res := address
+	segments := strings.Split(attributePath, ".")
+	for i, segment := range segments {
+		if block == nil {
+			return ""
+		}
+		res += "." + segment
+		if _, ok := block.Attributes[segment]; ok {
+			if i == len(segments)-1 {
+				return res
+			}
+			return ""
+		}
+		nestedBlock, ok := block.NestedBlocks[segment]
+		if !ok {
+			return ""
+		}
+		switch nestedBlock.NestingMode {
+		case schema.NestingList:
+			res += ".0"
+		case schema.NestingSingle, schema.NestingGroup:
+		default:
+			// the elements of sets and maps can't be referenced by index
+			return ""
+		}
+		block = nestedBlock.Block
+	}
+	return ""
+}
+
+func hasIndex(path string) bool {
+	for _, segment := range strings.Split(path, ".") {
+		if isIndex(segment) {
+			return true
+		}
+	}
+	return false
+}
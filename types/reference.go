@@ -0,0 +1,438 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// replacement replaces the bytes in range [start, end) of the source with text, the bytes are kept as they are if `keep` is true
+type replacement struct {
+	start int
+	end   int
+	text  string
+	keep  bool
+}
+
+// outputTarget is the output whose old name is parsed as traversal steps, e.g. [`azapi_resource`, `.test`, `[0]`, `.id`]
+type outputTarget struct {
+	steps   []string
+	newName string
+}
+
+// ReplaceReferences returns `src` of config file `filename` whose references to the old names of `outputs` are replaced by their new names.
+// The expressions are parsed and only the traversals starting with an old name are replaced, so `azapi_resource.test2` or the string
+// `"azapi_resource.test"` are not replaced by the output of `azapi_resource.test`. The index and splat forms are supported, e.g.
+// `azapi_resource.test[0].id` and `azapi_resource.test[*].id`. The other bytes are kept as they are.
+// The references in `removed`, `import` and `moved` blocks are not replaced, because they refer to the old resources.
+func ReplaceReferences(src []byte, filename string, outputs []Output) ([]byte, error) {
+	nodes, err := configNodes(src, filename)
+	if err != nil {
+		return nil, err
+	}
+	targets := outputTargets(outputs)
+	replacements := make([]replacement, 0)
+	for _, node := range nodes {
+		nodeReplacements := make([]replacement, 0)
+		_ = hclsyntax.VisitAll(node.node, func(n hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := n.(hclsyntax.Expression)
+			if !ok || isReplaced(nodeReplacements, expr.Range().Start.Byte) {
+				return nil
+			}
+			if r, ok := replaceTraversal(expr, targets); ok {
+				nodeReplacements = append(nodeReplacements, r)
+			}
+			return nil
+		})
+		if !hasReplacement(nodeReplacements) {
+			continue
+		}
+		if node.start == -1 {
+			replacements = append(replacements, nodeReplacements...)
+			continue
+		}
+		// the expression in JSON syntax is a string value, which is encoded again after replacement
+		replacements = append(replacements, replacement{
+			start: node.start,
+			end:   node.end,
+			text:  jsonString(applyReplacements(node.src, nodeReplacements)),
+		})
+	}
+	return applyReplacements(src, replacements), nil
+}
+
+// ListUnresolvedReferences returns the references to `addresses` in config files in working directory, e.g. `main.tf:12: azapi_resource.test.id`.
+// It's used to report the references which are not replaced after migration, the references in `removed`, `import` and `moved` blocks are excluded.
+func ListUnresolvedReferences(fs helper.FileSystem, workingDirectory string, addresses []string) ([]string, error) {
+	outputs := make([]Output, 0)
+	for _, address := range addresses {
+		outputs = append(outputs, Output{OldName: address, NewName: address})
+	}
+	targets := outputTargets(outputs)
+	res := make([]string, 0)
+	for _, file := range helper.ListConfigFiles(fs, workingDirectory) {
+		src, err := fs.ReadFile(filepath.Join(workingDirectory, file))
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := configNodes(src, file)
+		if err != nil {
+			log.Printf("[WARN] %+v", err)
+			continue
+		}
+		for _, node := range nodes {
+			_ = hclsyntax.VisitAll(node.node, func(n hclsyntax.Node) hcl.Diagnostics {
+				expr, ok := n.(*hclsyntax.ScopeTraversalExpr)
+				if !ok {
+					return nil
+				}
+				if steps, _ := traversalSteps(expr.Traversal); len(steps) != 0 {
+					if _, n := matchTarget(targets, steps); n != 0 {
+						line := expr.Range().Start.Line
+						if node.start != -1 {
+							line = bytes.Count(src[0:node.start], []byte("\n")) + 1
+						}
+						res = append(res, fmt.Sprintf("%s:%d: %s", file, line, expr.SrcRange.SliceBytes(node.src)))
+					}
+				}
+				return nil
+			})
+		}
+	}
+	return res, nil
+}
+
+// configNode is the root of the expressions in a config file. In native syntax, it's the body of a top level block and `src` is the file.
+// In JSON syntax, it's the expression in a string value and `src` is the decoded string, which is in range [start, end) of the file.
+type configNode struct {
+	node  hclsyntax.Node
+	src   []byte
+	start int
+	end   int
+}
+
+// configNodes returns the roots of the expressions in config `src`, the blocks of `removed`, `import` and `moved` are skipped.
+// In JSON syntax, the string values containing `${` are parsed as templates, and the ones in `depends_on` are parsed as traversals.
+func configNodes(src []byte, filename string) ([]configNode, error) {
+	res := make([]configNode, 0)
+	if helper.IsJsonConfig(filename) {
+		if !json.Valid(src) {
+			return nil, fmt.Errorf("parsing %s: invalid JSON", filename)
+		}
+		for _, value := range jsonStringValues(src) {
+			if len(value.path) == 0 || isStateBlock(value.path[0]) {
+				continue
+			}
+			var text string
+			if err := json.Unmarshal(src[value.start:value.end], &text); err != nil {
+				continue
+			}
+			var expr hclsyntax.Expression
+			var diags hcl.Diagnostics
+			switch {
+			case value.path[len(value.path)-1] == "depends_on":
+				expr, diags = hclsyntax.ParseExpression([]byte(text), filename, hcl.InitialPos)
+			case strings.Contains(text, "${"):
+				expr, diags = hclsyntax.ParseTemplate([]byte(text), filename, hcl.InitialPos)
+			default:
+				continue
+			}
+			if diags.HasErrors() {
+				continue
+			}
+			res = append(res, configNode{
+				node:  expr,
+				src:   []byte(text),
+				start: value.start,
+				end:   value.end,
+			})
+		}
+		return res, nil
+	}
+
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", filename, diags.Error())
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return res, nil
+	}
+	for _, block := range body.Blocks {
+		if isStateBlock(block.Type) {
+			continue
+		}
+		res = append(res, configNode{
+			node:  block.Body,
+			src:   src,
+			start: -1,
+			end:   -1,
+		})
+	}
+	return res, nil
+}
+
+// isStateBlock returns true if `blockType` is a block which refers to the resources in the state instead of the config
+func isStateBlock(blockType string) bool {
+	return blockType == "removed" || blockType == "import" || blockType == "moved"
+}
+
+// jsonValue is a string value in JSON, which is in range [start, end) of the source, `path` is the object keys of its ancestors
+type jsonValue struct {
+	start int
+	end   int
+	path  []string
+}
+
+// jsonStringValues returns the string values in valid JSON `src`, the object keys are not included
+func jsonStringValues(src []byte) []jsonValue {
+	type frame struct {
+		object    bool
+		key       string
+		expectKey bool
+	}
+	res := make([]jsonValue, 0)
+	stack := make([]*frame, 0)
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			stack = append(stack, &frame{object: true, expectKey: true})
+		case '[':
+			stack = append(stack, &frame{})
+		case '}', ']':
+			if len(stack) != 0 {
+				stack = stack[0 : len(stack)-1]
+			}
+		case ':':
+			if len(stack) != 0 {
+				stack[len(stack)-1].expectKey = false
+			}
+		case ',':
+			if len(stack) != 0 && stack[len(stack)-1].object {
+				stack[len(stack)-1].expectKey = true
+			}
+		case '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if len(stack) != 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+				_ = json.Unmarshal(src[i:end], &stack[len(stack)-1].key)
+			} else {
+				path := make([]string, 0)
+				for _, f := range stack {
+					if f.object {
+						path = append(path, f.key)
+					}
+				}
+				res = append(res, jsonValue{
+					start: i,
+					end:   end,
+					path:  path,
+				})
+			}
+			i = end - 1
+		}
+	}
+	return res
+}
+
+// jsonString returns the JSON string of `input`, the HTML characters are not escaped
+func jsonString(input []byte) string {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(string(input))
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// applyReplacements returns `src` with `replacements` applied, the replacements mustn't overlap
+func applyReplacements(src []byte, replacements []replacement) []byte {
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	res := append([]byte{}, src...)
+	for _, r := range replacements {
+		if r.keep {
+			continue
+		}
+		res = append(res[0:r.start], append([]byte(r.text), res[r.end:]...)...)
+	}
+	return res
+}
+
+// outputTargets parses the old names of `outputs`, the outputs whose old names aren't traversals are skipped
+func outputTargets(outputs []Output) []outputTarget {
+	res := make([]outputTarget, 0)
+	for _, output := range outputs {
+		expr, diags := hclsyntax.ParseExpression([]byte(output.OldName), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		if steps, _ := expressionSteps(expr); len(steps) != 0 {
+			res = append(res, outputTarget{
+				steps:   steps,
+				newName: output.NewName,
+			})
+		}
+	}
+	return res
+}
+
+// replaceTraversal returns the replacement of `expr` if it starts with the old name of a target
+func replaceTraversal(expr hclsyntax.Expression, targets []outputTarget) (replacement, bool) {
+	if splat, ok := expr.(*hclsyntax.SplatExpr); ok {
+		// azapi_resource.test[*].output.name is replaced as azapi_resource.test[0].output.name, then the index is changed back to splat
+		source, ok := splat.Source.(*hclsyntax.ScopeTraversalExpr)
+		each, ok2 := splat.Each.(*hclsyntax.RelativeTraversalExpr)
+		if !ok || !ok2 {
+			return replacement{}, false
+		}
+		sourceSteps, _ := traversalSteps(source.Traversal)
+		eachSteps, eachEnds := traversalSteps(each.Traversal)
+		if len(sourceSteps) != len(source.Traversal) || len(eachSteps) == 0 {
+			return replacement{}, false
+		}
+		steps := append(append(append([]string{}, sourceSteps...), "[0]"), eachSteps...)
+		target, n := matchTarget(targets, steps)
+		index := strings.Index(target.newName, "[0]")
+		if n > len(sourceSteps)+1 && target.newName == "" {
+			// the output isn't resolved, it's kept to be reported
+			return replacement{start: splat.Range().Start.Byte, end: splat.Range().End.Byte, keep: true}, true
+		}
+		if n <= len(sourceSteps)+1 || index == -1 {
+			// the address is replaced by the traversal in the source
+			return replacement{}, false
+		}
+		return replacement{
+			start: splat.Range().Start.Byte,
+			end:   eachEnds[n-len(sourceSteps)-2],
+			text:  target.newName[0:index] + "[*]" + target.newName[index+3:],
+		}, true
+	}
+
+	steps, ends := expressionSteps(expr)
+	target, n := matchTarget(targets, steps)
+	if n == 0 {
+		return replacement{}, false
+	}
+	if target.newName == "" {
+		// the output isn't resolved, so the traversal is kept instead of being replaced by a shorter old name, e.g. the resource address
+		return replacement{start: expr.Range().Start.Byte, end: ends[n-1], keep: true}, true
+	}
+	return replacement{
+		start: expr.Range().Start.Byte,
+		end:   ends[n-1],
+		text:  target.newName,
+	}, true
+}
+
+// matchTarget returns the target whose steps are the longest prefix of `steps`, and the number of the matched steps
+func matchTarget(targets []outputTarget, steps []string) (outputTarget, int) {
+	res := outputTarget{}
+	n := 0
+	for _, target := range targets {
+		if len(target.steps) <= n || len(target.steps) > len(steps) {
+			continue
+		}
+		matched := true
+		for i := range target.steps {
+			if target.steps[i] != steps[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			res = target
+			n = len(target.steps)
+		}
+	}
+	return res, n
+}
+
+// expressionSteps returns the traversal steps of `expr` and the end byte of each step, the expressions like `jsondecode(x.output).a`
+// are supported to match the references to the output of azapi provider v1. It returns nil if `expr` isn't a traversal.
+func expressionSteps(expr hclsyntax.Expression) ([]string, []int) {
+	switch e := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		return traversalSteps(e.Traversal)
+	case *hclsyntax.RelativeTraversalExpr:
+		call, ok := e.Source.(*hclsyntax.FunctionCallExpr)
+		if !ok || call.Name != "jsondecode" || len(call.Args) != 1 {
+			return nil, nil
+		}
+		arg, ok := call.Args[0].(*hclsyntax.ScopeTraversalExpr)
+		if !ok {
+			return nil, nil
+		}
+		argSteps, _ := traversalSteps(arg.Traversal)
+		steps := []string{fmt.Sprintf("jsondecode(%s)", strings.Join(argSteps, ""))}
+		ends := []int{call.Range().End.Byte}
+		relativeSteps, relativeEnds := traversalSteps(e.Traversal)
+		return append(steps, relativeSteps...), append(ends, relativeEnds...)
+	}
+	return nil, nil
+}
+
+// traversalSteps returns the steps of `traversal` in the form of `name`, `.name`, `[0]` or `["key"]`, and the end byte of each step.
+// The legacy index `.0` is converted to `[0]`, and the steps after a splat are omitted.
+func traversalSteps(traversal hcl.Traversal) ([]string, []int) {
+	steps := make([]string, 0)
+	ends := make([]int, 0)
+	for _, traverser := range traversal {
+		step := ""
+		switch t := traverser.(type) {
+		case hcl.TraverseRoot:
+			step = t.Name
+		case hcl.TraverseAttr:
+			step = "." + t.Name
+		case hcl.TraverseIndex:
+			switch {
+			case !t.Key.IsKnown() || t.Key.IsNull():
+				return steps, ends
+			case t.Key.Type() == cty.String:
+				step = fmt.Sprintf("[%q]", t.Key.AsString())
+			case t.Key.Type() == cty.Number:
+				step = fmt.Sprintf("[%s]", t.Key.AsBigFloat().Text('f', -1))
+			default:
+				return steps, ends
+			}
+		default:
+			return steps, ends
+		}
+		steps = append(steps, step)
+		ends = append(ends, traverser.SourceRange().End.Byte)
+	}
+	return steps, ends
+}
+
+func hasReplacement(replacements []replacement) bool {
+	for _, r := range replacements {
+		if !r.keep {
+			return true
+		}
+	}
+	return false
+}
+
+func isReplaced(replacements []replacement, pos int) bool {
+	for _, r := range replacements {
+		if pos >= r.start && pos < r.end {
+			return true
+		}
+	}
+	return false
+}
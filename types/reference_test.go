@@ -0,0 +1,218 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+)
+
+func Test_ReplaceReferences(t *testing.T) {
+	outputs := []types.Output{
+		{
+			OldName: "azapi_resource.test.output.properties.hostName",
+			NewName: "azurerm_redis_cache.test.hostname",
+		},
+		{
+			OldName: "azapi_resource.test",
+			NewName: "azurerm_redis_cache.test",
+		},
+		{
+			OldName: "jsondecode(azapi_resource.legacy.output).properties.hostName",
+			NewName: "azurerm_redis_cache.legacy.hostname",
+		},
+		{
+			OldName: "azapi_resource.legacy",
+			NewName: "azurerm_redis_cache.legacy",
+		},
+		{
+			OldName: "azurerm_key_vault.kv[0].vault_uri",
+			NewName: "azapi_resource.vault_kv[0].output.properties.vaultUri",
+		},
+		{
+			OldName: "azurerm_key_vault.kv[0]",
+			NewName: "azapi_resource.vault_kv[0]",
+		},
+		{
+			OldName: "azurerm_key_vault.kv[1]",
+			NewName: "azapi_resource.vault_kv[1]",
+		},
+		{
+			OldName: "azurerm_key_vault.kv",
+			NewName: "azapi_resource.vault_kv",
+		},
+	}
+	input := `
+resource "azapi_resource" "test2" {
+  # azapi_resource.test is in the comment
+  name = "azapi_resource.test"
+  body = {
+    host     = azapi_resource.test.output.properties.hostName
+    id       = azapi_resource.test.id
+    port     = azapi_resource.test.output.properties.port
+    other_id = azapi_resource.test2.id
+  }
+}
+
+output "hosts" {
+  value = [
+    jsondecode(azapi_resource.legacy.output).properties.hostName,
+    "${azapi_resource.legacy.id}/hosts",
+  ]
+}
+
+locals {
+  vault_uris = azurerm_key_vault.kv[*].vault_uri
+  vault_ids  = azurerm_key_vault.kv[*].id
+  vault_uri  = azurerm_key_vault.kv.0.vault_uri
+  vault_name = azurerm_key_vault.kv[1].name
+  vault      = azurerm_key_vault.kv[var.index]
+}
+
+moved {
+  from = azapi_resource.test
+  to   = azurerm_redis_cache.test
+}
+`
+	expected := `
+resource "azapi_resource" "test2" {
+  # azapi_resource.test is in the comment
+  name = "azapi_resource.test"
+  body = {
+    host     = azurerm_redis_cache.test.hostname
+    id       = azurerm_redis_cache.test.id
+    port     = azurerm_redis_cache.test.output.properties.port
+    other_id = azapi_resource.test2.id
+  }
+}
+
+output "hosts" {
+  value = [
+    azurerm_redis_cache.legacy.hostname,
+    "${azurerm_redis_cache.legacy.id}/hosts",
+  ]
+}
+
+locals {
+  vault_uris = azapi_resource.vault_kv[*].output.properties.vaultUri
+  vault_ids  = azapi_resource.vault_kv[*].id
+  vault_uri  = azapi_resource.vault_kv[0].output.properties.vaultUri
+  vault_name = azapi_resource.vault_kv[1].name
+  vault      = azapi_resource.vault_kv[var.index]
+}
+
+moved {
+  from = azapi_resource.test
+  to   = azurerm_redis_cache.test
+}
+`
+	actual, err := types.ReplaceReferences([]byte(input), "main.tf", outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func Test_ReplaceReferencesJson(t *testing.T) {
+	outputs := []types.Output{
+		{
+			OldName: "azapi_resource.test",
+			NewName: "azurerm_redis_cache.test",
+		},
+	}
+	input := `{
+  "output": {
+    "id": {
+      "value": "${azapi_resource.test.id}",
+      "description": "azapi_resource.test",
+      "depends_on": ["azapi_resource.test", "azapi_resource.test2"]
+    }
+  },
+  "moved": [
+    {
+      "from": "azapi_resource.test",
+      "to": "azurerm_redis_cache.test"
+    }
+  ]
+}`
+	expected := `{
+  "output": {
+    "id": {
+      "value": "${azurerm_redis_cache.test.id}",
+      "description": "azapi_resource.test",
+      "depends_on": ["azurerm_redis_cache.test", "azapi_resource.test2"]
+    }
+  },
+  "moved": [
+    {
+      "from": "azapi_resource.test",
+      "to": "azurerm_redis_cache.test"
+    }
+  ]
+}`
+	actual, err := types.ReplaceReferences([]byte(input), "main.tf.json", outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func Test_ListUnresolvedReferences(t *testing.T) {
+	outputs := []types.Output{
+		{
+			OldName: "azapi_resource.test.output.properties.unknown",
+			NewName: "",
+		},
+		{
+			OldName: "azapi_resource.test",
+			NewName: "azurerm_redis_cache.test",
+		},
+	}
+	input := `
+resource "azurerm_redis_firewall_rule" "test" {
+  dynamic "rule" {
+    for_each = azapi_resource.test.output.properties.unknown
+    content {
+      name = azapi_resource.test.name
+    }
+  }
+}
+
+check "health" {
+  assert {
+    condition     = azapi_resource.test.output.properties.unknown != null
+    error_message = "unknown"
+  }
+}
+`
+	dir := t.TempDir()
+	actual, err := types.ReplaceReferences([]byte(input), "main.tf", outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), actual, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "override.tf.json"), []byte(`{"locals": {"name": "${azapi_resource.test.name}"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	references, err := types.ListUnresolvedReferences(helper.OsFileSystem{}, dir, []string{"azapi_resource.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"main.tf:4: azapi_resource.test.output.properties.unknown",
+		"main.tf:13: azapi_resource.test.output.properties.unknown",
+		"override.tf.json:1: azapi_resource.test.name",
+	}
+	if !reflect.DeepEqual(references, expected) {
+		t.Fatalf("expect unresolved references %v, but got %v", expected, references)
+	}
+}
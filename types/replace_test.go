@@ -0,0 +1,131 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_ReplaceResourceBlockOptions(t *testing.T) {
+	config := `resource "azurerm_resource_group" "test" {
+  name     = "rg"
+  location = "westeurope"
+}
+
+resource "azurerm_virtual_network" "test" {
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.test.name
+}
+`
+	archived := `resource "azurerm_resource_group" "test" {
+  name     = "rg"
+  location = "westeurope"
+}
+`
+	migrated := `# aztfmigrate:generated
+moved {
+  from = azurerm_resource_group.test
+  to   = azapi_resource.test
+}
+
+resource "azapi_resource" "test" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "rg"
+}
+
+resource "azurerm_virtual_network" "test" {
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.test.name
+}
+`
+	remaining := `resource "azurerm_virtual_network" "test" {
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.test.name
+}
+`
+	migrations := `
+# aztfmigrate:generated
+moved {
+  from = azurerm_resource_group.test
+  to   = azapi_resource.test
+}
+
+resource "azapi_resource" "test" {
+  type = "Microsoft.Resources/resourceGroups@2024-03-01"
+  name = "rg"
+}
+`
+
+	testcases := []struct {
+		name     string
+		options  types.ReplaceOptions
+		expected map[string]string
+		missing  []string
+	}{
+		{
+			name:    "archive",
+			options: types.ReplaceOptions{Original: types.OriginalArchive, ArchiveDirectory: "archive"},
+			expected: map[string]string{
+				"main.tf": migrated,
+				filepath.Join("archive", "azurerm_resource_group.test.tf"): archived,
+			},
+		},
+		{
+			name:    "delete",
+			options: types.ReplaceOptions{Original: types.OriginalDelete, ArchiveDirectory: "archive"},
+			expected: map[string]string{
+				"main.tf": migrated,
+			},
+			missing: []string{"archive"},
+		},
+		{
+			name:    "delete with migrations file",
+			options: types.ReplaceOptions{Original: types.OriginalDelete, Filename: "migrations.tf"},
+			expected: map[string]string{
+				"main.tf":       remaining,
+				"migrations.tf": migrations,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			movedBlock := hclwrite.NewBlock("moved", nil)
+			movedBlock.Body().SetAttributeRaw("from", helper.GetTokensForExpression("azurerm_resource_group.test"))
+			movedBlock.Body().SetAttributeRaw("to", helper.GetTokensForExpression("azapi_resource.test"))
+			newBlock := hclwrite.NewBlock("resource", []string{"azapi_resource", "test"})
+			newBlock.Body().SetAttributeRaw("type", helper.GetTokensForExpression(`"Microsoft.Resources/resourceGroups@2024-03-01"`))
+			newBlock.Body().SetAttributeRaw("name", helper.GetTokensForExpression(`"rg"`))
+
+			options := tc.options
+			if options.ArchiveDirectory != "" {
+				options.ArchiveDirectory = filepath.Join(dir, options.ArchiveDirectory)
+			}
+			if err := types.ReplaceResourceBlock(helper.OsFileSystem{}, dir, types.Module{}, "azurerm_resource_group.test", []*hclwrite.Block{movedBlock, newBlock}, options); err != nil {
+				t.Fatal(err)
+			}
+			for file, expected := range tc.expected {
+				actual, err := os.ReadFile(filepath.Join(dir, file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(actual) != expected {
+					t.Fatalf("expect %s:\n%s\nbut got:\n%s", file, expected, actual)
+				}
+			}
+			for _, file := range tc.missing {
+				if _, err := os.Stat(filepath.Join(dir, file)); !os.IsNotExist(err) {
+					t.Fatalf("expect %s not to exist, but got %v", file, err)
+				}
+			}
+		})
+	}
+}
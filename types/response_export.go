@@ -0,0 +1,179 @@
+package types
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/Azure/aztfmigrate/azurerm/coverage"
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/tf"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// azapiResourceAttributes are the attributes of azurerm resources which azapi resource has too, they're referenced by the new address
+var azapiResourceAttributes = map[string]bool{
+	"id":       true,
+	"name":     true,
+	"location": true,
+	"tags":     true,
+	"identity": true,
+}
+
+// AppendAttributeOutputs adds `references` to the attributes of resource `r` to its outputs, e.g. `azurerm_key_vault.kv.vault_uri`.
+// After migration, they're replaced by the references to the response properties of the azapi resource, which are exported.
+func AppendAttributeOutputs(r AzureResource, references []string) {
+	resource, ok := r.(*AzurermResource)
+	if !ok {
+		return
+	}
+	for i, instance := range resource.Instances {
+		valueMap := getPropValueMap(instance.Values, "")
+		for _, ref := range references {
+			attribute, ok := strings.CutPrefix(ref, resource.OldAddress(instance.Index)+".")
+			if !ok || azapiResourceAttributes[strings.Split(attribute, ".")[0]] {
+				continue
+			}
+			resource.Instances[i].Outputs = append(resource.Instances[i].Outputs, Output{
+				OldName: ref,
+				Value:   valueMap["."+attributePath(attribute)],
+			})
+		}
+	}
+}
+
+// attributePath converts the traversal of an attribute to a path separated by `.`, e.g. `network_acls.0.bypass` for `network_acls[0].bypass`
+func attributePath(traversal string) string {
+	return strings.NewReplacer("[", ".", "]", "", `"`, "").Replace(traversal)
+}
+
+// resolveAttributeOutputs sets the new names of the outputs of the instance at `index` to its response properties, `typeValue` is the
+// `type` of the migrated azapi resource. The attributes are mapped by the coverage report, and the others are matched by value in the
+// response. The new names of the outputs which can't be resolved are left empty, so their references are kept and reported.
+func (r *AzurermResource) resolveAttributeOutputs(terraform *tf.Terraform, index int, typeValue string) {
+	instance := r.Instances[index]
+	address := r.NewAddress(instance.Index)
+	outputs, toMatch, unresolved := r.mapAttributeOutputs(index)
+
+	if len(toMatch) != 0 {
+		block, err := newDataSourceBlock(r.NewLabel, instance.ResourceId, typeValue)
+		if err == nil {
+			var values map[string]interface{}
+			if values, err = terraform.ReadDataSource(block); err == nil {
+				var matched []Output
+				matched, toMatch = MatchResponseProperties(toMatch, values["output"], address+".output")
+				for _, output := range matched {
+					output.NewName = AzapiOutputReference(address, azapiOutputPath(output.NewName, address), r.AzapiVersion)
+					outputs = append(outputs, output)
+				}
+			}
+		}
+		if err != nil {
+			log.Printf("[WARN] reading the response of %s: %+v", address, err)
+		}
+		unresolved = append(unresolved, toMatch...)
+	}
+	for _, output := range unresolved {
+		log.Printf("[WARN] %s can't be mapped to a response property of %s, please update its references manually", output.OldName, address)
+		output.NewName = ""
+		outputs = append(outputs, output)
+	}
+	r.Instances[index].Outputs = sortOutputs(outputs)
+}
+
+// mapAttributeOutputs returns the outputs of the instance at `index` which are mapped to the response properties by the coverage report,
+// the outputs which need to be matched by value in the response, and the outputs which can't be resolved because their values are unknown
+func (r *AzurermResource) mapAttributeOutputs(index int) ([]Output, []Output, []Output) {
+	instance := r.Instances[index]
+	address := r.NewAddress(instance.Index)
+	idPattern, _ := GetIdPattern(instance.ResourceId)
+	mapped := make([]Output, 0)
+	toMatch := make([]Output, 0)
+	unresolved := make([]Output, 0)
+	for _, output := range instance.Outputs {
+		path := attributePath(strings.TrimPrefix(output.OldName, r.OldAddress(instance.Index)+"."))
+		if !hasIndex(path) {
+			if prop := coverage.GetPropertyPath(path, idPattern); prop != "" {
+				output.NewName = AzapiOutputReference(address, prop, r.AzapiVersion)
+				mapped = append(mapped, output)
+				continue
+			}
+		}
+		if output.Value == nil {
+			unresolved = append(unresolved, output)
+			continue
+		}
+		toMatch = append(toMatch, output)
+	}
+	return mapped, toMatch, unresolved
+}
+
+// dataSourceBlocks returns the data sources which read the responses of the instances whose outputs need to be matched by value,
+// the `type` of each data source is found in the config generated by the batch import
+func (r *AzurermResource) dataSourceBlocks(terraform *tf.Terraform) []*hclwrite.Block {
+	res := make([]*hclwrite.Block, 0)
+	for i, instance := range r.Instances {
+		if _, toMatch, _ := r.mapAttributeOutputs(i); len(toMatch) == 0 {
+			continue
+		}
+		config, ok := terraform.ImportedConfig(r.instanceAddress(instance))
+		if !ok {
+			continue
+		}
+		f, diags := hclwrite.ParseConfig([]byte(config), "", hcl.InitialPos)
+		if diags.HasErrors() || len(f.Body().Blocks()) == 0 {
+			continue
+		}
+		if block, err := newDataSourceBlock(r.NewLabel, instance.ResourceId, typeValueOf(f.Body().Blocks()[0])); err == nil {
+			res = append(res, block)
+		}
+	}
+	return res
+}
+
+// MatchResponseProperties sets the new names of `outputs` to the properties of `response` which hold the same values, the names are prefixed
+// by `prefix`. An output is only matched if exactly one property holds its value, and the empty and boolean values are never matched,
+// because they're likely to be held by an unrelated property. It returns the matched outputs and the unmatched ones.
+func MatchResponseProperties(outputs []Output, response interface{}, prefix string) ([]Output, []Output) {
+	if output, ok := response.(string); ok {
+		var outputObj interface{}
+		if err := json.Unmarshal([]byte(output), &outputObj); err == nil {
+			response = outputObj
+		}
+	}
+	valuePaths := make(map[string][]string)
+	for key, value := range getPropValueMap(response, prefix) {
+		stringValue := Output{Value: value}.GetStringValue()
+		valuePaths[stringValue] = append(valuePaths[stringValue], key)
+	}
+	matched := make([]Output, 0)
+	unmatched := make([]Output, 0)
+	for _, output := range outputs {
+		paths := valuePaths[output.GetStringValue()]
+		switch output.Value.(type) {
+		case bool, nil:
+			paths = nil
+		case string:
+			if output.Value == "" {
+				paths = nil
+			}
+		}
+		if len(paths) != 1 {
+			unmatched = append(unmatched, output)
+			continue
+		}
+		output.NewName = paths[0]
+		matched = append(matched, output)
+	}
+	return matched, unmatched
+}
+
+// typeValueOf returns the value of `type` attribute of azapi `block`
+func typeValueOf(block *hclwrite.Block) string {
+	if block == nil || block.Body().GetAttribute("type") == nil {
+		return ""
+	}
+	typeValue, _ := helper.GetValueFromExpression(block.Body().GetAttribute("type").Expr().BuildTokens(nil)).(string)
+	return typeValue
+}
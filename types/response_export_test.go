@@ -0,0 +1,115 @@
+package types_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+)
+
+func Test_AppendAttributeOutputs(t *testing.T) {
+	config := `
+resource "azurerm_key_vault" "test" {
+  count = 2
+  name  = "kv${count.index}"
+}
+
+resource "azurerm_key_vault" "test2" {
+  name = "kv2"
+}
+
+output "vault_uri" {
+  value = azurerm_key_vault.test[0].vault_uri
+}
+
+locals {
+  vault_id        = azurerm_key_vault.test[1].id
+  bypass          = azurerm_key_vault.test[1].network_acls[0].bypass
+  other_vault_uri = azurerm_key_vault.test2.vault_uri
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	references, err := types.ListAttributeReferences(helper.OsFileSystem{}, dir, "azurerm_key_vault.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReferences := []string{
+		"azurerm_key_vault.test[0].vault_uri",
+		"azurerm_key_vault.test[1].id",
+		"azurerm_key_vault.test[1].network_acls[0].bypass",
+	}
+	if !reflect.DeepEqual(references, expectedReferences) {
+		t.Fatalf("expect references %v, but got %v", expectedReferences, references)
+	}
+
+	r := &types.AzurermResource{
+		OldResourceType: "azurerm_key_vault",
+		OldLabel:        "test",
+		Instances: []types.Instance{
+			{
+				Index:  0,
+				Values: map[string]interface{}{"vault_uri": "https://kv0.vault.azure.net/"},
+			},
+			{
+				Index: 1,
+				Values: map[string]interface{}{
+					"network_acls": []interface{}{map[string]interface{}{"bypass": "AzureServices"}},
+				},
+			},
+		},
+	}
+	types.AppendAttributeOutputs(r, references)
+	if outputs := r.Instances[0].Outputs; len(outputs) != 1 || outputs[0].OldName != "azurerm_key_vault.test[0].vault_uri" || outputs[0].Value != "https://kv0.vault.azure.net/" {
+		t.Fatalf("expect the output of vault_uri, but got %v", outputs)
+	}
+	// id is referenced by the new address, so it's not in the outputs
+	if outputs := r.Instances[1].Outputs; len(outputs) != 1 || outputs[0].OldName != "azurerm_key_vault.test[1].network_acls[0].bypass" || outputs[0].Value != "AzureServices" {
+		t.Fatalf("expect the output of network_acls.0.bypass, but got %v", outputs)
+	}
+}
+
+func Test_MatchResponseProperties(t *testing.T) {
+	response := `{"properties":{"vaultUri":"https://kv0.vault.azure.net/","sku":{"name":"standard","family":"A"},"tier":"standard","enableSoftDelete":true,"description":"","retentionDays":90}}`
+	outputs := []types.Output{
+		{OldName: "azurerm_key_vault.test.vault_uri", Value: "https://kv0.vault.azure.net/"},
+		{OldName: "azurerm_key_vault.test.soft_delete_retention_days", Value: float64(90)},
+		// both sku.name and tier hold the value, so it's ambiguous
+		{OldName: "azurerm_key_vault.test.sku_name", Value: "standard"},
+		{OldName: "azurerm_key_vault.test.enable_rbac_authorization", Value: true},
+		{OldName: "azurerm_key_vault.test.description", Value: ""},
+		{OldName: "azurerm_key_vault.test.tenant_id", Value: "00000000-0000-0000-0000-000000000000"},
+	}
+	matched, unmatched := types.MatchResponseProperties(outputs, response, "azapi_resource.test.output")
+
+	expectedMatched := map[string]string{
+		"azurerm_key_vault.test.vault_uri":                  "azapi_resource.test.output.properties.vaultUri",
+		"azurerm_key_vault.test.soft_delete_retention_days": "azapi_resource.test.output.properties.retentionDays",
+	}
+	if len(matched) != len(expectedMatched) {
+		t.Fatalf("expect %d matched outputs, but got %v", len(expectedMatched), matched)
+	}
+	for _, output := range matched {
+		if expectedMatched[output.OldName] != output.NewName {
+			t.Errorf("expect %s to match %s, but got %s", output.OldName, expectedMatched[output.OldName], output.NewName)
+		}
+	}
+	expectedUnmatched := []string{
+		"azurerm_key_vault.test.sku_name",
+		"azurerm_key_vault.test.enable_rbac_authorization",
+		"azurerm_key_vault.test.description",
+		"azurerm_key_vault.test.tenant_id",
+	}
+	actualUnmatched := make([]string, 0)
+	for _, output := range unmatched {
+		actualUnmatched = append(actualUnmatched, output.OldName)
+	}
+	if !reflect.DeepEqual(actualUnmatched, expectedUnmatched) {
+		t.Fatalf("expect unmatched outputs %v, but got %v", expectedUnmatched, actualUnmatched)
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// newMovedBlock returns the `moved` block which moves resource `oldType`.`oldLabel` to `newType`.`newLabel` in `module`,
+// moving a resource across providers requires terraform 1.8+ and the new provider implements MoveResourceState
+func newMovedBlock(module Module, oldType, oldLabel, newType, newLabel string) *hclwrite.Block {
+	movedBlock := hclwrite.NewBlock("moved", nil)
+	movedBlock.Body().SetAttributeTraversal("from", module.Traversal(oldType, oldLabel))
+	movedBlock.Body().SetAttributeTraversal("to", module.Traversal(newType, newLabel))
+	return movedBlock
+}
+
+// newRemovedBlock returns the `removed` block which removes resource `resourceType`.`label` in `module` from the state without destroying it
+func newRemovedBlock(module Module, resourceType, label string) *hclwrite.Block {
+	removedBlock := hclwrite.NewBlock("removed", nil)
+	removedBlock.Body().SetAttributeTraversal("from", module.Traversal(resourceType, label))
+	removedLifecycleBlock := hclwrite.NewBlock("lifecycle", nil)
+	removedLifecycleBlock.Body().SetAttributeValue("destroy", cty.BoolVal(false))
+	removedBlock.Body().AppendBlock(removedLifecycleBlock)
+	return removedBlock
+}
+
+// newImportBlock returns the `import` block which imports `instances` to resource `resourceType`.`label` in `module`, the instances of
+// a multiple resource are imported by `for_each`. `importId` returns the import id of an instance.
+func newImportBlock(module Module, resourceType, label string, instances []Instance, multiple bool, importId func(Instance) string) *hclwrite.Block {
+	importBlock := hclwrite.NewBlock("import", nil)
+	if multiple {
+		forEachMap := make(map[string]cty.Value)
+		for _, instance := range instances {
+			switch v := instance.Index.(type) {
+			case string:
+				forEachMap[importId(instance)] = cty.StringVal(v)
+			default:
+				value, _ := strconv.ParseInt(fmt.Sprintf("%v", v), 10, 64)
+				forEachMap[importId(instance)] = cty.NumberIntVal(value)
+			}
+		}
+		importBlock.Body().SetAttributeValue("for_each", cty.MapVal(forEachMap))
+		importBlock.Body().SetAttributeTraversal("id", hcl.Traversal{hcl.TraverseRoot{Name: "each"}, hcl.TraverseAttr{Name: "key"}})
+		importBlock.Body().SetAttributeTraversal("to", module.Traversal(resourceType, fmt.Sprintf("%s[each.value]", label)))
+	} else {
+		importBlock.Body().SetAttributeValue("id", cty.StringVal(importId(instances[0])))
+		importBlock.Body().SetAttributeTraversal("to", module.Traversal(resourceType, label))
+	}
+	return importBlock
+}
@@ -0,0 +1,88 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfmigrate/helper"
+	"github.com/Azure/aztfmigrate/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func Test_StateUpdateBlocks(t *testing.T) {
+	block := hclwrite.NewBlock("resource", []string{"azapi_resource", "test"})
+	block.Body().SetAttributeRaw("type", helper.GetTokensForExpression(`"Microsoft.Resources/resourceGroups@2024-03-01"`))
+	id := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	testcases := []struct {
+		Name     string
+		Resource types.AzureResource
+		Expect   string
+	}{
+		{
+			Name: "azapi to azurerm removed and imported",
+			Resource: &types.AzapiResource{
+				Label:        "test",
+				ResourceType: "azurerm_resource_group",
+				Instances:    []types.Instance{{ResourceId: id}},
+			},
+			Expect: `removed {
+  from = azapi_resource.test
+  lifecycle {
+    destroy = false
+  }
+}
+import {
+  id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+  to = azurerm_resource_group.test
+}
+`,
+		},
+		{
+			Name: "azurerm to azapi moved",
+			Resource: &types.AzurermResource{
+				OldResourceType: "azurerm_resource_group",
+				OldLabel:        "test",
+				NewResourceType: "azapi_resource",
+				NewLabel:        "test",
+				Block:           block,
+				Instances:       []types.Instance{{ResourceId: id}},
+				MovedSupported:  true,
+			},
+			Expect: `moved {
+  from = azurerm_resource_group.test
+  to   = azapi_resource.test
+}
+`,
+		},
+		{
+			Name: "azurerm to azapi removed and imported",
+			Resource: &types.AzurermResource{
+				OldResourceType: "azurerm_resource_group",
+				OldLabel:        "test",
+				NewResourceType: "azapi_resource",
+				NewLabel:        "test",
+				Block:           block,
+				Instances:       []types.Instance{{ResourceId: id}},
+			},
+			Expect: `removed {
+  from = azurerm_resource_group.test
+  lifecycle {
+    destroy = false
+  }
+}
+import {
+  id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg?api-version=2024-03-01"
+  to = azapi_resource.test
+}
+`,
+		},
+	}
+	for _, testcase := range testcases {
+		f := hclwrite.NewEmptyFile()
+		for _, b := range testcase.Resource.StateUpdateBlocks() {
+			f.Body().AppendBlock(b)
+		}
+		if actual := string(hclwrite.Format(f.Bytes())); actual != testcase.Expect {
+			t.Errorf("%s: expect:\n%s\nbut got:\n%s", testcase.Name, testcase.Expect, actual)
+		}
+	}
+}
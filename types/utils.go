@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -21,20 +22,25 @@ func getApiVersion(value interface{}) string {
 }
 
 func getId(value interface{}) string {
-	if valueMap, ok := value.(map[string]interface{}); ok && valueMap["id"] != nil {
-		if resourceId, ok := valueMap["id"].(string); ok {
-			return resourceId
+	return getStringAttribute(value, "id")
+}
+
+func getStringAttribute(value interface{}, name string) string {
+	if valueMap, ok := value.(map[string]interface{}); ok && valueMap[name] != nil {
+		if stringValue, ok := valueMap[name].(string); ok {
+			return stringValue
 		}
 	}
 	return ""
 }
 
-func getOutputsForAddress(address string, refValueMap map[string]interface{}) []Output {
+// getOutputsForAddress returns the outputs of `address` which is relative to `module`, the output names are relative to `module` too
+func getOutputsForAddress(module Module, address string, refValueMap map[string]interface{}) []Output {
 	res := make([]Output, 0)
 	for key, value := range refValueMap {
-		if strings.HasPrefix(key, fmt.Sprintf("%s.output.", address)) {
+		if strings.HasPrefix(key, fmt.Sprintf("%s.output.", module.Qualify(address))) {
 			res = append(res, Output{
-				OldName: key,
+				OldName: strings.TrimPrefix(key, module.Qualify("")),
 				Value:   value,
 			})
 		}
@@ -42,9 +48,14 @@ func getOutputsForAddress(address string, refValueMap map[string]interface{}) []
 	return res
 }
 
-func getReferencesForAddress(address string, p *tfjson.Plan, refValueMap map[string]interface{}) []Reference {
+// getReferencesForAddress returns the references used by `address` which is relative to `module`
+func getReferencesForAddress(module Module, address string, p *tfjson.Plan, refValueMap map[string]interface{}) []Reference {
 	res := make([]Reference, 0)
-	for _, r := range p.Config.RootModule.Resources {
+	configModule := findConfigModule(p, module.Address)
+	if configModule == nil {
+		return res
+	}
+	for _, r := range configModule.Resources {
 		if r.Address == address {
 			for _, expression := range r.Expressions {
 				res = append(res, listReferences(expression)...)
@@ -80,7 +91,7 @@ func getReferencesForAddress(address string, p *tfjson.Plan, refValueMap map[str
 	}
 	res = make([]Reference, 0)
 	for _, ref := range refSet {
-		ref.Value = refValueMap[ref.Name]
+		ref.Value = refValueMap[module.Qualify(ref.Name)]
 		res = append(res, ref)
 	}
 	return res
@@ -107,36 +118,49 @@ func getRefValueMap(p *tfjson.Plan) map[string]interface{} {
 		if resourceChange == nil || resourceChange.Change == nil || resourceChange.Change.Before == nil {
 			continue
 		}
-		prefix := resourceChange.Address
-		if strings.HasPrefix(prefix, "azapi") {
-			if beforeMap, ok := resourceChange.Change.Before.(map[string]interface{}); ok && beforeMap["output"] != nil {
-				if output, ok := beforeMap["output"].(string); ok {
-					var outputObj interface{}
-					if err := json.Unmarshal([]byte(output), &outputObj); err == nil {
-						propValueMap := getPropValueMap(outputObj, fmt.Sprintf("jsondecode(%s.output)", prefix))
+		addRefValues(refValueMap, resourceChange.Address, resourceChange.Type, resourceChange.Change.Before)
+	}
+	// data sources are not in the resource changes
+	if p.PriorState != nil && p.PriorState.Values != nil {
+		for _, stateResource := range listStateResources(p.PriorState.Values.RootModule) {
+			if stateResource.Mode == tfjson.DataResourceMode {
+				addRefValues(refValueMap, stateResource.Address, stateResource.Type, stateResource.AttributeValues)
+			}
+		}
+	}
+	for key, variable := range p.Variables {
+		refValueMap["var."+key] = variable.Value
+	}
+	return refValueMap
+}
+
+// addRefValues adds the values of resource `address` to `refValueMap`, the key is the reference to the value
+func addRefValues(refValueMap map[string]interface{}, address string, resourceType string, values interface{}) {
+	prefix := address
+	if strings.HasPrefix(resourceType, "azapi") {
+		if beforeMap, ok := values.(map[string]interface{}); ok && beforeMap["output"] != nil {
+			if output, ok := beforeMap["output"].(string); ok {
+				var outputObj interface{}
+				if err := json.Unmarshal([]byte(output), &outputObj); err == nil {
+					propValueMap := getPropValueMap(outputObj, fmt.Sprintf("jsondecode(%s.output)", prefix))
+					for key, value := range propValueMap {
+						refValueMap[key] = value
+					}
+				} else {
+					if outputObj := beforeMap["output"]; outputObj != nil {
+						propValueMap := getPropValueMap(outputObj, fmt.Sprintf("%s.output", prefix))
 						for key, value := range propValueMap {
 							refValueMap[key] = value
 						}
-					} else {
-						if outputObj := beforeMap["output"]; outputObj != nil {
-							propValueMap := getPropValueMap(outputObj, fmt.Sprintf("%s.output", prefix))
-							for key, value := range propValueMap {
-								refValueMap[key] = value
-							}
-						}
 					}
 				}
 			}
 		}
-		propValueMap := getPropValueMap(resourceChange.Change.Before, prefix)
-		for key, value := range propValueMap {
-			refValueMap[key] = value
-		}
 	}
-	for key, variable := range p.Variables {
-		refValueMap["var."+key] = variable.Value
+	propValueMap := getPropValueMap(values, prefix)
+	for key, value := range propValueMap {
+		refValueMap[key] = value
 	}
-	return refValueMap
 }
 
 func getPropValueMap(input interface{}, prefix string) map[string]interface{} {
@@ -250,3 +274,11 @@ func removeIndexOfProp(prop string) string {
 	}
 	return strings.Join(res, ".")
 }
+
+var instanceKeyRegex = regexp.MustCompile(`\[[^\]]*\]`)
+
+// ResourceAddress returns the address of the resource without the instance keys, e.g. `module.a.azurerm_resource_group.test` for
+// `module.a["x"].azurerm_resource_group.test[0]`
+func ResourceAddress(address string) string {
+	return instanceKeyRegex.ReplaceAllString(address, "")
+}